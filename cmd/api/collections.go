@@ -0,0 +1,276 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) listCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string
+		Mine bool
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Name = app.readString(qs, "name", "")
+	input.Mine = app.readBool(qs, "mine", false, v)
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "-created_at")
+	input.SortWhiteList = []string{"id", "name", "created_at", "updated_at", "-id", "-name", "-created_at", "-updated_at"}
+
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	collections, metadata, err := app.models.Collection.GetAll(user.ID, input.Mine, input.Name, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list collections handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"collections": collections, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list collections handler: %s", err))
+	}
+}
+
+func (app *application) createCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Visibility  string  `json:"visibility"`
+		MovieIDs    []int64 `json:"movie_ids"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	collection := &data.Collection{
+		OwnerID:     app.contextGetUser(r).ID,
+		Name:        input.Name,
+		Description: input.Description,
+		Visibility:  input.Visibility,
+		MovieIDs:    input.MovieIDs,
+	}
+	if collection.Visibility == "" {
+		collection.Visibility = data.VisibilityPrivate
+	}
+	if collection.MovieIDs == nil {
+		collection.MovieIDs = []int64{}
+	}
+
+	v := validator.New()
+	data.ValidateCollection(v, collection)
+	if v.IsValid() {
+		err = app.checkCollectionMovies(v, collection.MovieIDs)
+		if err != nil {
+			app.serverErrorResponse(w, r, fmt.Errorf("create collection handler: %s", err))
+			return
+		}
+	}
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Collection.Insert(collection)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create collection handler: %s", err))
+		return
+	}
+
+	header := http.Header{}
+	header.Set("Location", fmt.Sprintf("/v1/collections/%d", collection.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, header, envelope{"collection": collection})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create collection handler: %s", err))
+	}
+}
+
+func (app *application) showCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.readVisibleCollection(w, r)
+	if !ok {
+		return
+	}
+
+	movies, err := app.models.Collection.GetMovies(collection.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("show collection handler: %s", err))
+		return
+	}
+	collection.Movies = movies
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"collection": collection})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("show collection handler: %s", err))
+	}
+}
+
+func (app *application) updateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.readOwnedCollection(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Visibility  *string `json:"visibility"`
+		MovieIDs    []int64 `json:"movie_ids"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		collection.Name = *input.Name
+	}
+
+	if input.Description != nil {
+		collection.Description = *input.Description
+	}
+
+	if input.Visibility != nil {
+		collection.Visibility = *input.Visibility
+	}
+
+	if input.MovieIDs != nil {
+		collection.MovieIDs = input.MovieIDs
+	}
+
+	v := validator.New()
+	data.ValidateCollection(v, collection)
+	if v.IsValid() && input.MovieIDs != nil {
+		err = app.checkCollectionMovies(v, collection.MovieIDs)
+		if err != nil {
+			app.serverErrorResponse(w, r, fmt.Errorf("update collection handler: %s", err))
+			return
+		}
+	}
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Collection.Update(collection)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update collection handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"collection": collection})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("update collection handler: %s", err))
+	}
+}
+
+func (app *application) deleteCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.readOwnedCollection(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Collection.Delete(collection.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete collection handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "collection successfully deleted"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("delete collection handler: %s", err))
+	}
+}
+
+func (app *application) readVisibleCollection(w http.ResponseWriter, r *http.Request) (*data.Collection, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	collection, err := app.models.Collection.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("read collection: %s", err))
+		}
+		return nil, false
+	}
+
+	if !collection.VisibleTo(app.contextGetUser(r)) {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	return collection, true
+}
+
+func (app *application) readOwnedCollection(w http.ResponseWriter, r *http.Request) (*data.Collection, bool) {
+	collection, ok := app.readVisibleCollection(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if collection.OwnerID != app.contextGetUser(r).ID {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	return collection, true
+}
+
+func (app *application) checkCollectionMovies(v *validator.Validator, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	found, err := app.models.Movie.GetByIDs(ids, []string{"id"})
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[int64]bool, len(found))
+	for _, movie := range found {
+		exists[movie.ID] = true
+	}
+
+	for _, id := range ids {
+		if !exists[id] {
+			v.AddFieldError("movie_ids", fmt.Sprintf("movie with id %d does not exist", id))
+			break
+		}
+	}
+
+	return nil
+}
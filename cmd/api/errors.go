@@ -28,11 +28,20 @@ func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Reques
 	app.errorResponse(w, r, http.StatusBadRequest, err.Error())
 }
 
+func (app *application) unsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorResponse(w, r, http.StatusUnsupportedMediaType, err.Error())
+}
+
 func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Request) {
 	message := "unable to update the record due to an edit conflict, please try again"
 	app.errorResponse(w, r, http.StatusConflict, message)
 }
 
+func (app *application) preconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the resource has been modified since the version specified in the If-Match header"
+	app.errorResponse(w, r, http.StatusPreconditionFailed, message)
+}
+
 func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errs map[string]string) {
 	app.errorResponse(w, r, http.StatusUnprocessableEntity, errs)
 }
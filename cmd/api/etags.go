@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+)
+
+func movieETag(movie *data.Movie, weak bool) string {
+	// Ratings change without a version bump, so they are folded into the tag
+	// to keep cached representations fresh.
+	etag := fmt.Sprintf(`"%d-%d-%d-%.2f"`, movie.ID, movie.Version, movie.RatingCount, movie.AverageRating)
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+func etagMatches(headerVal, etag string, weak bool) bool {
+	if headerVal == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(headerVal, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(candidate, "W/") || strings.HasPrefix(etag, "W/") {
+			continue
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func movieIfMatch(headerVal string, movie *data.Movie) bool {
+	// Only the ID and version are compared so that reviews landing between
+	// a read and a write do not fail the precondition.
+	prefix := fmt.Sprintf(`"%d-%d-`, movie.ID, movie.Version)
+	for _, candidate := range strings.Split(headerVal, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.HasPrefix(candidate, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (app *application) notModifiedResponse(w http.ResponseWriter, header http.Header) {
+	for key, val := range header {
+		w.Header()[key] = val
+	}
+	w.WriteHeader(http.StatusNotModified)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) listGenresHandler(w http.ResponseWriter, r *http.Request) {
+	genres, err := app.models.Genre.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list genres handler: %s", err))
+		return
+	}
+
+	err = app.writeJSONWithWeakETag(w, r, nil, envelope{"genres": genres})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list genres handler: %s", err))
+	}
+}
+
+func (app *application) createGenreHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name    string   `json:"name"`
+		Aliases []string `json:"aliases"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	genre := &data.Genre{
+		Name:    strings.TrimSpace(input.Name),
+		Aliases: input.Aliases,
+	}
+	if genre.Aliases == nil {
+		genre.Aliases = []string{}
+	}
+
+	catalog, err := app.models.Genre.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create genre handler: %s", err))
+		return
+	}
+
+	v := validator.New()
+	data.ValidateGenre(v, genre, catalog, "")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Genre.Insert(genre)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateGenre):
+			v.AddFieldError("name", "a genre with this name or alias already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("create genre handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, nil, envelope{"genre": genre})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create genre handler: %s", err))
+	}
+}
+
+func (app *application) updateGenreHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	genre, err := app.models.Genre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update genre handler: %s", err))
+		}
+		return
+	}
+
+	var input struct {
+		Name    *string  `json:"name"`
+		Aliases []string `json:"aliases"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	previousName := genre.Name
+	if input.Aliases != nil {
+		genre.Aliases = input.Aliases
+	}
+
+	if input.Name != nil {
+		genre.Name = strings.TrimSpace(*input.Name)
+		if !strings.EqualFold(genre.Name, previousName) && !containsFold(genre.Aliases, previousName) {
+			genre.Aliases = append(genre.Aliases, previousName)
+		}
+	}
+
+	catalog, err := app.models.Genre.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("update genre handler: %s", err))
+		return
+	}
+
+	v := validator.New()
+	data.ValidateGenre(v, genre, catalog, previousName)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Genre.Update(genre, previousName, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateGenre):
+			v.AddFieldError("name", "a genre with this name or alias already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update genre handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"genre": genre})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("update genre handler: %s", err))
+	}
+}
+
+func (app *application) mergeGenreHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Into int64 `json:"into"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.CheckError(input.Into > 0, "into", "must be provided")
+	v.CheckError(input.Into != id, "into", "must not be the genre being merged")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	source, err := app.models.Genre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("merge genre handler: %s", err))
+		}
+		return
+	}
+
+	target, err := app.models.Genre.Get(input.Into)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddFieldError("into", "genre does not exist")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("merge genre handler: %s", err))
+		}
+		return
+	}
+
+	rewritten, err := app.models.Genre.Merge(source, target, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("merge genre handler: %s", err))
+		}
+		return
+	}
+
+	target, err = app.models.Genre.Get(target.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("merge genre handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"genre": target, "movies_rewritten": rewritten})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("merge genre handler: %s", err))
+	}
+}
+
+func containsFold(values []string, s string) bool {
+	for _, val := range values {
+		if strings.EqualFold(strings.TrimSpace(val), s) {
+			return true
+		}
+	}
+
+	return false
+}
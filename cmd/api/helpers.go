@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,6 +31,42 @@ func (app *application) readIDParam(r *http.Request) (int64, error) {
 	return id, nil
 }
 
+func (app *application) readVersionParam(r *http.Request) (int32, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+	rawVersion := params.ByName("version")
+	version, err := strconv.ParseInt(rawVersion, 10, 32)
+
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid version %q", rawVersion)
+	}
+
+	return int32(version), nil
+}
+
+func (app *application) readCreditIDParam(r *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+	rawID := params.ByName("credit_id")
+	id, err := strconv.ParseInt(rawID, 10, 64)
+
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid credit id %q", rawID)
+	}
+
+	return id, nil
+}
+
+func (app *application) dispatchIDParam(static map[string]http.HandlerFunc, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := httprouter.ParamsFromContext(r.Context())
+		if handler, ok := static[params.ByName("id")]; ok {
+			handler(w, r)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
 func (app *application) readString(qs url.Values, key, defaultVal string) string {
 	val := qs.Get(key)
 
@@ -66,12 +103,103 @@ func (app *application) readInt(qs url.Values, key string, defaultVal int, v *va
 	return i
 }
 
+func (app *application) readFloat(qs url.Values, key string, defaultVal float64, v *validator.Validator) float64 {
+	val := qs.Get(key)
+
+	if val == "" {
+		return defaultVal
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		v.AddFieldError(key, "must be a number")
+		return defaultVal
+	}
+
+	return f
+}
+
+func (app *application) readBool(qs url.Values, key string, defaultVal bool, v *validator.Validator) bool {
+	val := qs.Get(key)
+
+	if val == "" {
+		return defaultVal
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		v.AddFieldError(key, "must be a boolean value")
+		return defaultVal
+	}
+
+	return b
+}
+
+func (app *application) readIDs(qs url.Values, key string, v *validator.Validator) []int64 {
+	ids := []int64{}
+	for _, val := range app.readCSV(qs, key, []string{}) {
+		id, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		if err != nil || id <= 0 {
+			v.AddFieldError(key, "must contain only positive integer values")
+			return nil
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func (app *application) readTime(qs url.Values, key string, v *validator.Validator) time.Time {
+	val := qs.Get(key)
+
+	if val == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		v.AddFieldError(key, "must be a RFC 3339 timestamp")
+		return time.Time{}
+	}
+
+	return t
+}
+
 func (app *application) writeJSON(w http.ResponseWriter, status int, header http.Header, data envelope) error {
 	resBody, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
+	app.writeRawJSON(w, status, header, resBody)
+	return nil
+}
+
+func (app *application) writeJSONWithWeakETag(w http.ResponseWriter, r *http.Request, header http.Header, data envelope) error {
+	resBody, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if header == nil {
+		header = http.Header{}
+	}
+
+	sum := sha256.Sum256(resBody)
+	etag := fmt.Sprintf(`W/"%x"`, sum[:16])
+	header.Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		app.notModifiedResponse(w, header)
+		return nil
+	}
+
+	app.writeRawJSON(w, http.StatusOK, header, resBody)
+	return nil
+}
+
+func (app *application) writeRawJSON(w http.ResponseWriter, status int, header http.Header, resBody []byte) {
 	resBody = append(resBody, '\n')
 
 	for key, val := range header {
@@ -80,7 +208,6 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, header http
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	w.Write(resBody)
-	return nil
 }
 
 func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
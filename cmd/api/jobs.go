@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (app *application) purgeDeletedMovies(ctx context.Context) {
+	ticker := time.NewTicker(app.cfg.movies.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, imageKeys, err := app.models.Movie.PurgeDeleted(ctx, app.cfg.movies.trashRetention)
+		if err != nil {
+			app.logger.Error(fmt.Errorf("purge deleted movies: %s", err), nil)
+			continue
+		}
+		app.deleteStoredFiles(imageKeys)
+
+		if purged > 0 {
+			app.logger.Info("purged deleted movies", map[string]string{
+				"count":     fmt.Sprint(purged),
+				"retention": app.cfg.movies.trashRetention.String(),
+			})
+		}
+	}
+}
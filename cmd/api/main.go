@@ -17,6 +17,7 @@ import (
 	"huytran2000-hcmus/greenlight/internal/data"
 	"huytran2000-hcmus/greenlight/internal/jsonlog"
 	"huytran2000-hcmus/greenlight/internal/mailer"
+	"huytran2000-hcmus/greenlight/internal/storage"
 	"huytran2000-hcmus/greenlight/internal/vcs"
 )
 
@@ -32,9 +33,11 @@ type config struct {
 		maxIdleTime  string
 	}
 	limiter struct {
-		rate   float64
-		burst  int
-		enable bool
+		rate         float64
+		burst        int
+		suggestRate  float64
+		suggestBurst int
+		enable       bool
 	}
 	smtp struct {
 		host     string
@@ -46,14 +49,30 @@ type config struct {
 	cors struct {
 		trustedOrigins []string
 	}
+	storage struct {
+		dir     string
+		baseURL string
+	}
+	movies struct {
+		batchMax         int
+		importMaxBytes   int64
+		posterMaxBytes   int64
+		backdropMaxBytes int64
+		exportTimeout    time.Duration
+		trashRetention   time.Duration
+		purgeInterval    time.Duration
+		statsCacheTTL    time.Duration
+	}
 }
 
 type application struct {
-	logger *jsonlog.Logger
-	cfg    config
-	models data.Models
-	mailer *mailer.Mailer
-	wg     sync.WaitGroup
+	logger     *jsonlog.Logger
+	cfg        config
+	models     data.Models
+	mailer     *mailer.Mailer
+	storage    storage.Storage
+	statsCache movieStatsCache
+	wg         sync.WaitGroup
 }
 
 func main() {
@@ -73,6 +92,8 @@ func main() {
 	)
 	flag.Float64Var(&cfg.limiter.rate, "limiter-rate", 2, "Rate limiter average request per seconds")
 	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum request burst")
+	flag.Float64Var(&cfg.limiter.suggestRate, "limiter-suggest-rate", 10, "Rate limiter average request per seconds for movie suggestions")
+	flag.IntVar(&cfg.limiter.suggestBurst, "limiter-suggest-burst", 20, "Rate limiter maximum request burst for movie suggestions")
 	flag.BoolVar(&cfg.limiter.enable, "limiter-enable", true, "Rate limiter enable")
 
 	flag.StringVar(&cfg.smtp.host, "smtp-host", "sandbox.smtp.mailtrap.io", "SMTP host")
@@ -81,6 +102,18 @@ func main() {
 	flag.StringVar(&cfg.smtp.password, "smtp-password", "e1b6e7d0b660b5", "SMTP password")
 	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "Greenlight <no-reply@greenlight.alexedwards.net>", "SMTP sender")
 
+	flag.IntVar(&cfg.movies.batchMax, "movies-batch-max", 100, "Maximum number of movies fetched by id in a single request")
+	flag.DurationVar(&cfg.movies.exportTimeout, "movies-export-timeout", 10*time.Minute, "Deadline for streaming a movie export")
+	flag.DurationVar(&cfg.movies.trashRetention, "movies-trash-retention", 30*24*time.Hour, "How long deleted movies are kept before being purged (0 disables purging)")
+	flag.DurationVar(&cfg.movies.purgeInterval, "movies-purge-interval", time.Hour, "Interval between purges of deleted movies (0 disables purging)")
+	flag.Int64Var(&cfg.movies.importMaxBytes, "movies-import-max-bytes", 32<<20, "Maximum size in bytes of a movie import request body")
+	flag.DurationVar(&cfg.movies.statsCacheTTL, "movies-stats-cache-ttl", 5*time.Minute, "How long catalogue statistics are cached")
+	flag.Int64Var(&cfg.movies.posterMaxBytes, "movies-poster-max-bytes", 10<<20, "Maximum size in bytes of a movie poster upload")
+	flag.Int64Var(&cfg.movies.backdropMaxBytes, "movies-backdrop-max-bytes", 20<<20, "Maximum size in bytes of a movie backdrop upload")
+
+	flag.StringVar(&cfg.storage.dir, "storage-dir", "./uploads", "Directory where uploaded files are stored")
+	flag.StringVar(&cfg.storage.baseURL, "storage-base-url", "/v1/media", "Public URL prefix of uploaded files (served by the API when it is a path)")
+
 	flag.Func("cors-trusted-origins", "Trusted CORS origins", func(val string) error {
 		cfg.cors.trustedOrigins = strings.Fields(val)
 		return nil
@@ -113,6 +146,11 @@ func main() {
 		logger.FatalErr(err, nil)
 	}
 
+	store, err := storage.NewFileSystem(cfg.storage.dir, cfg.storage.baseURL)
+	if err != nil {
+		logger.FatalErr(err, nil)
+	}
+
 	expvar.NewString("version").Set(version)
 	expvar.Publish("goroutines", expvar.Func(func() any {
 		return runtime.NumGoroutine()
@@ -125,10 +163,11 @@ func main() {
 	}))
 
 	app := &application{
-		logger: logger,
-		cfg:    cfg,
-		models: models,
-		mailer: mailer,
+		logger:  logger,
+		cfg:     cfg,
+		models:  models,
+		mailer:  mailer,
+		storage: store,
 	}
 
 	err = app.serve()
@@ -18,6 +18,14 @@ import (
 )
 
 func (app *application) rateLimit(next http.Handler) http.Handler {
+	return app.limitRate(app.cfg.limiter.rate, app.cfg.limiter.burst, next)
+}
+
+func (app *application) suggestRateLimit(next http.Handler) http.Handler {
+	return app.limitRate(app.cfg.limiter.suggestRate, app.cfg.limiter.suggestBurst, next)
+}
+
+func (app *application) limitRate(rps float64, burst int, next http.Handler) http.Handler {
 	var mu sync.Mutex
 	type client struct {
 		limiter  *rate.Limiter
@@ -50,7 +58,7 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 		mu.Lock()
 		if _, ok := clients[ip]; !ok {
 			clients[ip] = &client{
-				limiter: rate.NewLimiter(rate.Limit(app.cfg.limiter.rate), app.cfg.limiter.burst),
+				limiter: rate.NewLimiter(rate.Limit(rps), burst),
 			}
 		}
 
@@ -116,9 +124,10 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 			for _, org := range app.cfg.cors.trustedOrigins {
 				if org == origin {
 					w.Header().Add("Access-Control-Allow-Origin", origin)
+					w.Header().Add("Access-Control-Expose-Headers", "ETag")
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						w.Header().Add("Access-Control-Allow-Method", "OPTIONS, PUT, PATCH, DELETE")
-						w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Authorization")
+						w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, If-None-Match")
 						w.WriteHeader(http.StatusOK)
 						return
 					}
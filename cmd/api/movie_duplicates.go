@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) listDuplicateMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Threshold float64
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Threshold = app.readFloat(qs, "threshold", data.DefaultDuplicateThreshold, v)
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = "id"
+	input.SortWhiteList = []string{"id"}
+
+	data.ValidateDuplicateThreshold(v, input.Threshold)
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	clusters, metadata, err := app.models.Movie.GetDuplicateClusters(input.Threshold, input.Filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrTooManyDuplicatePairs):
+			v.AddFieldError("threshold", "matches too many pairs of movies, use a higher threshold")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("list duplicate movies handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"clusters": clusters, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list duplicate movies handler: %s", err))
+	}
+}
+
+func (app *application) mergeMovieHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		SourceID int64 `json:"source_id"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.CheckError(input.SourceID > 0, "source_id", "must be provided")
+	v.CheckError(input.SourceID != id, "source_id", "must not be the movie being merged into")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(input.SourceID, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddFieldError("source_id", "movie does not exist")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("merge movie handler: %s", err))
+		}
+		return
+	}
+
+	report, err := app.models.Movie.Merge(id, input.SourceID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("merge movie handler: %s", err))
+		}
+		return
+	}
+
+	movie, err := app.models.Movie.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("merge movie handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movie": movie, "merged": report})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("merge movie handler: %s", err))
+	}
+}
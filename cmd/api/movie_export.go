@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+const exportFlushEvery = 100
+
+func (app *application) exportMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+		data.MovieFilter
+		Format string
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.MovieFilter = app.readMovieFilter(qs, v)
+	input.Format = app.readString(qs, "format", "csv")
+	input.Sort = app.readString(qs, "sort", "id")
+	input.SortWhiteList = movieSortWhiteList
+
+	v.CheckError(validator.PermittedValue(input.Format, "csv", "ndjson"), "format", "must be csv or ndjson")
+	v.CheckError(validator.PermittedValue(input.Sort, input.SortWhiteList...), "sort", "invalid sort value")
+	v.CheckError(input.Sort != "relevance" || input.Title != "", "sort", "relevance sort requires a title search")
+	data.ValidateMovieFilter(v, input.MovieFilter)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	deadline := time.Now().Add(app.cfg.movies.exportTimeout)
+	rc := http.NewResponseController(w)
+	err := rc.SetWriteDeadline(deadline)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("export movies handler: %s", err))
+		return
+	}
+
+	ctx, cancel := context.WithDeadline(r.Context(), deadline)
+	defer cancel()
+
+	var write func(*data.Movie) error
+	var flush func() error
+	switch input.Format {
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		write = func(mv *data.Movie) error { return enc.Encode(mv) }
+		flush = func() error { return nil }
+	default:
+		w.Header().Set("Content-Type", "text/csv")
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "title", "year", "runtime", "genres", "version"})
+		write = func(mv *data.Movie) error {
+			return cw.Write([]string{
+				strconv.FormatInt(mv.ID, 10),
+				mv.Title,
+				strconv.FormatInt(int64(mv.Year), 10),
+				strconv.FormatInt(int64(mv.Runtime), 10),
+				strings.Join(mv.Genres, "|"),
+				strconv.FormatInt(int64(mv.Version), 10),
+			})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="movies.%s"`, input.Format))
+
+	written := 0
+	err = app.models.Movie.Stream(ctx, input.MovieFilter, input.Filters, func(mv *data.Movie) error {
+		err := write(mv)
+		if err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			err = flush()
+			if err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	if err != nil {
+		if written == 0 {
+			w.Header().Del("Content-Disposition")
+			app.serverErrorResponse(w, r, fmt.Errorf("export movies handler: %s", err))
+			return
+		}
+
+		app.logError(r, fmt.Errorf("export movies handler: aborted after %d movies: %s", written, err))
+	}
+}
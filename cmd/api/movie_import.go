@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+const (
+	importBatchSize = 1000
+	importDeadline  = 5 * time.Minute
+	maxImportErrors = 1000
+)
+
+type importRowError struct {
+	Row    int               `json:"row"`
+	Errors map[string]string `json:"errors"`
+}
+
+type importReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total_rows"`
+	Accepted int              `json:"accepted"`
+	Rejected int              `json:"rejected"`
+	Errors   []importRowError `json:"errors"`
+}
+
+type movieRowReader interface {
+	next() (row int, movie *data.Movie, errs map[string]string, err error)
+}
+
+func (app *application) importMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+	dryRun := app.readBool(qs, "dry_run", false, v)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	format, err := importFormat(r)
+	if err != nil {
+		app.unsupportedMediaTypeResponse(w, r, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Now().Add(importDeadline))
+	rc.SetWriteDeadline(time.Now().Add(importDeadline))
+
+	body := http.MaxBytesReader(w, r.Body, app.cfg.movies.importMaxBytes)
+	rows, err := newMovieRowReader(format, body)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	genres, err := app.models.Genre.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("import movies handler: %s", err))
+		return
+	}
+
+	// Every row is staged in one transaction so that a failure part way
+	// through the body leaves nothing behind.
+	var movieImport *data.MovieImport
+	if !dryRun {
+		ctx, cancel := context.WithTimeout(r.Context(), importDeadline)
+		defer cancel()
+
+		movieImport, err = app.models.Movie.BeginImport(ctx, app.contextGetUser(r).ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, fmt.Errorf("import movies handler: %s", err))
+			return
+		}
+		defer movieImport.Rollback()
+	}
+
+	report := importReport{DryRun: dryRun, Errors: []importRowError{}}
+	batch := make([]*data.Movie, 0, importBatchSize)
+	for {
+		row, movie, errs, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				err = fmt.Errorf("request body must not be larger than %d bytes", maxBytesErr.Limit)
+			}
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		report.Total++
+		if errs == nil {
+			v := validator.New()
+			data.ValidateMovie(v, movie, genres)
+			if !v.IsValid() {
+				errs = v.Errors
+			}
+		}
+
+		if errs != nil {
+			report.Rejected++
+			if len(report.Errors) < maxImportErrors {
+				report.Errors = append(report.Errors, importRowError{Row: row, Errors: errs})
+			}
+			continue
+		}
+
+		report.Accepted++
+		if dryRun {
+			continue
+		}
+
+		batch = append(batch, movie)
+		if len(batch) == importBatchSize {
+			err = movieImport.Add(batch)
+			if err != nil {
+				app.serverErrorResponse(w, r, fmt.Errorf("import movies handler: %s", err))
+				return
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if !dryRun {
+		if len(batch) > 0 {
+			err = movieImport.Add(batch)
+			if err != nil {
+				app.serverErrorResponse(w, r, fmt.Errorf("import movies handler: %s", err))
+				return
+			}
+		}
+
+		err = movieImport.Commit()
+		if err != nil {
+			app.serverErrorResponse(w, r, fmt.Errorf("import movies handler: %s", err))
+			return
+		}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"report": report})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("import movies handler: %s", err))
+	}
+}
+
+func importFormat(r *http.Request) (string, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			return "", errors.New("Content-Type must be text/csv or application/x-ndjson")
+		}
+
+		switch mediaType {
+		case "text/csv":
+			format = "csv"
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			format = "ndjson"
+		}
+	}
+
+	if format != "csv" && format != "ndjson" {
+		return "", errors.New("Content-Type must be text/csv or application/x-ndjson")
+	}
+
+	return format, nil
+}
+
+func newMovieRowReader(format string, body io.Reader) (movieRowReader, error) {
+	if format == "ndjson" {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1_048_576)
+		return &ndjsonMovieReader{scanner: scanner}, nil
+	}
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("request body is empty")
+		}
+		return nil, fmt.Errorf("read csv header: %s", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"title", "year", "runtime", "genres"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header must contain a %q column", name)
+		}
+	}
+
+	return &csvMovieReader{reader: reader, columns: columns, row: 1}, nil
+}
+
+type csvMovieReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	row     int
+}
+
+func (cr *csvMovieReader) next() (int, *data.Movie, map[string]string, error) {
+	record, err := cr.reader.Read()
+	cr.row++
+
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		return cr.row, nil, map[string]string{"row": parseErr.Err.Error()}, nil
+	case err != nil:
+		return cr.row, nil, nil, err
+	}
+
+	field := func(name string) string {
+		i := cr.columns[name]
+		if i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	v := validator.New()
+	movie := &data.Movie{Title: field("title")}
+
+	if year := field("year"); year != "" {
+		i, err := strconv.ParseInt(year, 10, 32)
+		v.CheckError(err == nil, "year", "must be an integer value")
+		movie.Year = int32(i)
+	}
+
+	if runtime := field("runtime"); runtime != "" {
+		i, err := strconv.ParseInt(strings.TrimSuffix(runtime, " mins"), 10, 32)
+		v.CheckError(err == nil, "runtime", "must be an integer number of minutes")
+		movie.Runtime = data.RunTime(i)
+	}
+
+	if genres := field("genres"); genres != "" {
+		movie.Genres = []string{}
+		for _, genre := range strings.Split(genres, "|") {
+			movie.Genres = append(movie.Genres, strings.TrimSpace(genre))
+		}
+	}
+
+	if !v.IsValid() {
+		return cr.row, nil, v.Errors, nil
+	}
+
+	return cr.row, movie, nil, nil
+}
+
+type ndjsonMovieReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func (nr *ndjsonMovieReader) next() (int, *data.Movie, map[string]string, error) {
+	for nr.scanner.Scan() {
+		nr.row++
+
+		line := bytes.TrimSpace(nr.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var input struct {
+			Title   string       `json:"title"`
+			Year    int32        `json:"year"`
+			Runtime data.RunTime `json:"runtime"`
+			Genres  []string     `json:"genres"`
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&input)
+		if err != nil {
+			return nr.row, nil, map[string]string{"row": err.Error()}, nil
+		}
+
+		return nr.row, &data.Movie{
+			Title:   input.Title,
+			Year:    input.Year,
+			Runtime: input.Runtime,
+			Genres:  input.Genres,
+		}, nil, nil
+	}
+
+	err := nr.scanner.Err()
+	if err != nil {
+		return nr.row, nil, nil, err
+	}
+
+	return nr.row, nil, nil, io.EOF
+}
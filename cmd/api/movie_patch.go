@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/jsonpatch"
+)
+
+const (
+	mergePatchMediaType = "application/merge-patch+json"
+	jsonPatchMediaType  = "application/json-patch+json"
+)
+
+func patchMediaType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case mergePatchMediaType, jsonPatchMediaType:
+		return mediaType
+	default:
+		return ""
+	}
+}
+
+func (app *application) applyMoviePatch(w http.ResponseWriter, r *http.Request, mediaType string, movie *data.Movie) (map[string]string, error) {
+	var maxBytes int64 = 1_048_576
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("request body must not be larger than %d bytes", maxBytesErr.Limit)
+		}
+		return nil, err
+	}
+
+	if len(patch) == 0 {
+		return nil, errors.New("request body is empty")
+	}
+
+	doc, err := json.Marshal(map[string]any{
+		"title":   movie.Title,
+		"year":    movie.Year,
+		"runtime": movie.Runtime,
+		"genres":  movie.Genres,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch mediaType {
+	case mergePatchMediaType:
+		doc, err = jsonpatch.MergePatch(doc, patch)
+	default:
+		doc, err = jsonpatch.Apply(doc, patch)
+	}
+	if err != nil {
+		var opErr *jsonpatch.OperationError
+		if errors.As(err, &opErr) {
+			return map[string]string{opErr.Path: opErr.Error()}, nil
+		}
+		return nil, err
+	}
+
+	return decodeMoviePatchDocument(doc, movie)
+}
+
+func decodeMoviePatchDocument(doc []byte, movie *data.Movie) (map[string]string, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(doc, &fields)
+	if err != nil {
+		return map[string]string{"": "patched document must be a JSON object"}, nil
+	}
+
+	// Only the patchable fields come from the document, anything it no
+	// longer contains is cleared so that validation reports it as missing.
+	errs := map[string]string{}
+	movie.Title, movie.Year, movie.Runtime, movie.Genres = "", 0, 0, nil
+
+	for key, raw := range fields {
+		var err error
+		switch key {
+		case "title":
+			err = json.Unmarshal(raw, &movie.Title)
+		case "year":
+			err = json.Unmarshal(raw, &movie.Year)
+		case "runtime":
+			err = json.Unmarshal(raw, &movie.Runtime)
+		case "genres":
+			err = json.Unmarshal(raw, &movie.Genres)
+		default:
+			errs["/"+key] = "unknown or read-only field"
+			continue
+		}
+
+		if err != nil {
+			errs["/"+key] = "contains an incorrect JSON type or format"
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs, nil
+	}
+
+	return nil, nil
+}
+
+func pointerErrors(errs map[string]string) map[string]string {
+	pointers := make(map[string]string, len(errs))
+	for key, message := range errs {
+		pointers["/"+key] = message
+	}
+
+	return pointers
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/imaging"
+)
+
+const (
+	maxImageDimension = 10_000
+	maxImagePixels    = 40_000_000
+)
+
+type thumbnailSize struct {
+	name  string
+	width int
+}
+
+var posterThumbnailSizes = []thumbnailSize{
+	{"w92", 92},
+	{"w185", 185},
+	{"w342", 342},
+}
+
+var backdropThumbnailSizes = []thumbnailSize{
+	{"w300", 300},
+	{"w780", 780},
+	{"w1280", 1280},
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var thumbnailContentTypes = map[string]string{
+	"image/jpeg": "image/jpeg",
+	"image/png":  "image/png",
+	// There is no WebP encoder, so PNG is used to keep any transparency.
+	"image/webp": "image/png",
+}
+
+type movieImageSetter func(movie *data.Movie, img data.MovieImage, userID int64) ([]string, error)
+
+func (app *application) uploadMoviePosterHandler(w http.ResponseWriter, r *http.Request) {
+	app.uploadMovieImage(w, r, "poster", app.cfg.movies.posterMaxBytes, posterThumbnailSizes, app.models.Movie.SetPoster)
+}
+
+func (app *application) uploadMovieBackdropHandler(w http.ResponseWriter, r *http.Request) {
+	app.uploadMovieImage(w, r, "backdrop", app.cfg.movies.backdropMaxBytes, backdropThumbnailSizes, app.models.Movie.SetBackdrop)
+}
+
+func (app *application) uploadMovieImage(w http.ResponseWriter, r *http.Request, kind string, maxBytes int64, sizes []thumbnailSize, set movieImageSetter) {
+	handler := fmt.Sprintf("upload movie %s handler", kind)
+
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	movie, err := app.models.Movie.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("%s: %s", handler, err))
+		}
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch != "" && !movieIfMatch(ifMatch, movie) {
+		app.preconditionFailedResponse(w, r)
+		return
+	}
+
+	body, err := app.readImageUpload(w, r, kind, maxBytes)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	contentType := http.DetectContentType(body)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		app.unsupportedMediaTypeResponse(w, r, fmt.Errorf("%s must be a JPEG, PNG or WebP image", kind))
+		return
+	}
+
+	img, err := decodeImage(body)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{kind: err.Error()})
+		return
+	}
+
+	prefix, err := imagePrefix(kind, movie.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("%s: %s", handler, err))
+		return
+	}
+
+	stored, err := app.storeImage(r.Context(), prefix, ext, contentType, body, img, sizes)
+	if err != nil {
+		app.deleteStoredFiles(stored.Keys)
+		app.serverErrorResponse(w, r, fmt.Errorf("%s: %s", handler, err))
+		return
+	}
+
+	oldKeys, err := set(movie, stored, app.contextGetUser(r).ID)
+	if err != nil {
+		app.deleteStoredFiles(stored.Keys)
+		switch {
+		case errors.Is(err, data.ErrEditConflict) && ifMatch != "":
+			app.preconditionFailedResponse(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("%s: %s", handler, err))
+		}
+		return
+	}
+	app.deleteStoredFiles(oldKeys)
+
+	header := http.Header{}
+	header.Set("ETag", movieETag(movie, false))
+
+	err = app.writeJSON(w, http.StatusOK, header, envelope{"movie": movie})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("%s: %s", handler, err))
+	}
+}
+
+func (app *application) readImageUpload(w http.ResponseWriter, r *http.Request, field string, maxBytes int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	var src io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, fmt.Errorf("read multipart body: %s", err)
+		}
+
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("multipart body must contain a %q file", field)
+			}
+			if err != nil {
+				return nil, imageReadError(field, err)
+			}
+
+			if part.FormName() == field {
+				src = part
+				break
+			}
+		}
+	}
+
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return nil, imageReadError(field, err)
+	}
+
+	if len(body) == 0 {
+		return nil, fmt.Errorf("%s must not be empty", field)
+	}
+
+	return body, nil
+}
+
+func imageReadError(field string, err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%s must not be larger than %d bytes", field, maxBytesErr.Limit)
+	}
+
+	return fmt.Errorf("read %s: %s", field, err)
+}
+
+func decodeImage(body []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("must be a valid image")
+	}
+
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, fmt.Errorf("must not be larger than %dx%d pixels", maxImageDimension, maxImageDimension)
+	}
+
+	// Decoding allocates four bytes per pixel, so the total is capped before
+	// any pixel data is read.
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("must not have more than %d pixels", maxImagePixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("must be a valid image")
+	}
+
+	return img, nil
+}
+
+func imagePrefix(kind string, movieID int64) (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("generate %s key: %s", kind, err)
+	}
+
+	return fmt.Sprintf("%ss/%d/%s", kind, movieID, hex.EncodeToString(b)), nil
+}
+
+func (app *application) storeImage(ctx context.Context, prefix, ext, contentType string, body []byte, img image.Image, sizes []thumbnailSize) (data.MovieImage, error) {
+	stored := data.MovieImage{Thumbnails: data.ImageThumbnails{}}
+
+	key := prefix + "/original" + ext
+	err := app.storage.Put(ctx, key, bytes.NewReader(body), contentType)
+	if err != nil {
+		return stored, err
+	}
+	stored.Keys = append(stored.Keys, key)
+	stored.URL = app.storage.URL(key)
+
+	thumbType := thumbnailContentTypes[contentType]
+	thumbExt := imageExtensions[thumbType]
+
+	// Thumbnails are built from the largest down, each one resized from the
+	// previous, so the full-size image is only scanned once.
+	src := img
+	for i := len(sizes) - 1; i >= 0; i-- {
+		size := sizes[i]
+		if src.Bounds().Dx() <= size.width {
+			continue
+		}
+
+		buf := new(bytes.Buffer)
+		thumb := imaging.Resize(src, size.width)
+		if thumbType == "image/png" {
+			err = png.Encode(buf, thumb)
+		} else {
+			err = jpeg.Encode(buf, thumb, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return stored, fmt.Errorf("encode %s thumbnail: %s", size.name, err)
+		}
+
+		key := prefix + "/" + size.name + thumbExt
+		err = app.storage.Put(ctx, key, buf, thumbType)
+		if err != nil {
+			return stored, err
+		}
+		stored.Keys = append(stored.Keys, key)
+		stored.Thumbnails[size.name] = app.storage.URL(key)
+		src = thumb
+	}
+
+	return stored, nil
+}
+
+func (app *application) deleteStoredFiles(keys []string) {
+	for _, key := range keys {
+		err := app.storage.Delete(context.Background(), key)
+		if err != nil {
+			app.logger.Error(err, map[string]string{"key": key})
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) listSimilarMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	input, languages, ok := app.readScoredMoviesInput(w, r)
+	if !ok {
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("list similar movies handler: %s", err))
+		}
+		return
+	}
+
+	movies, metadata, err := app.models.Movie.GetSimilar(id, app.contextGetUser(r).ID, input.Fields, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list similar movies handler: %s", err))
+		return
+	}
+
+	err = app.models.Translation.Localise(movies, languages)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list similar movies handler: %s", err))
+		return
+	}
+	w.Header().Add("Vary", "Accept-Language")
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movies": projectMovies(movies, input.Fields), "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list similar movies handler: %s", err))
+	}
+}
+
+func (app *application) listRecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	input, languages, ok := app.readScoredMoviesInput(w, r)
+	if !ok {
+		return
+	}
+
+	movies, metadata, err := app.models.Movie.GetRecommendations(app.contextGetUser(r).ID, input.Fields, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list recommendations handler: %s", err))
+		return
+	}
+
+	err = app.models.Translation.Localise(movies, languages)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list recommendations handler: %s", err))
+		return
+	}
+	w.Header().Add("Vary", "Accept-Language")
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movies": projectMovies(movies, input.Fields), "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list recommendations handler: %s", err))
+	}
+}
+
+type scoredMoviesInput struct {
+	data.Filters
+	Fields []string
+}
+
+func (app *application) readScoredMoviesInput(w http.ResponseWriter, r *http.Request) (scoredMoviesInput, []string, bool) {
+	var input scoredMoviesInput
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Fields = app.readCSV(qs, "fields", []string{})
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = "-score"
+	input.SortWhiteList = []string{"-score"}
+	languages := app.readLanguages(r, v)
+
+	data.ValidateFilter(v, input.Filters)
+	data.ValidateMovieFields(v, input.Fields)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return input, nil, false
+	}
+
+	return input, languages, true
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) listMovieRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "-version")
+	input.SortWhiteList = []string{"version", "-version"}
+
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("list movie revisions handler: %s", err))
+		}
+		return
+	}
+
+	revisions, metadata, err := app.models.MovieRevision.GetAllForMovie(id, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list movie revisions handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"revisions": revisions, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list movie revisions handler: %s", err))
+	}
+}
+
+func (app *application) showMovieRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	version, err := app.readVersionParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	revision, err := app.models.MovieRevision.Get(id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("show movie revision handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"revision": revision})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("show movie revision handler: %s", err))
+	}
+}
+
+func (app *application) restoreMovieRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	version, err := app.readVersionParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	movie, err := app.models.Movie.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("restore movie revision handler: %s", err))
+		}
+		return
+	}
+
+	revision, err := app.models.MovieRevision.Get(id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("restore movie revision handler: %s", err))
+		}
+		return
+	}
+
+	revision.After.ApplyTo(movie)
+
+	genres, err := app.models.Genre.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("restore movie revision handler: %s", err))
+		return
+	}
+
+	v := validator.New()
+	data.ValidateMovie(v, movie, genres)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Movie.Update(movie, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("restore movie revision handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movie": movie})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("restore movie revision handler: %s", err))
+	}
+}
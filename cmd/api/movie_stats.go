@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+)
+
+type movieStatsCache struct {
+	mu        sync.Mutex
+	stats     *data.MovieStats
+	expiresAt time.Time
+}
+
+func (app *application) movieStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, expiresAt, err := app.cachedMovieStats()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("movie stats handler: %s", err))
+		return
+	}
+
+	header := http.Header{}
+	header.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(time.Until(expiresAt).Seconds())))
+
+	err = app.writeJSON(w, http.StatusOK, header, envelope{"stats": stats})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("movie stats handler: %s", err))
+	}
+}
+
+func (app *application) cachedMovieStats() (*data.MovieStats, time.Time, error) {
+	cache := &app.statsCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.stats != nil && time.Now().Before(cache.expiresAt) {
+		return cache.stats, cache.expiresAt, nil
+	}
+
+	stats, err := app.models.Movie.GetStats()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	cache.stats = stats
+	cache.expiresAt = time.Now().Add(app.cfg.movies.statsCacheTTL)
+
+	return cache.stats, cache.expiresAt, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+const maxPreferredLanguages = 10
+
+func (app *application) listMovieTranslationsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("list movie translations handler: %s", err))
+		}
+		return
+	}
+
+	translations, err := app.models.Translation.GetAllForMovie(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list movie translations handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"translations": translations})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list movie translations handler: %s", err))
+	}
+}
+
+func (app *application) putMovieTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Title    string `json:"title"`
+		Overview string `json:"overview"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	translation := &data.MovieTranslation{
+		MovieID:  id,
+		Language: app.readLanguageParam(r),
+		Title:    strings.TrimSpace(input.Title),
+		Overview: strings.TrimSpace(input.Overview),
+	}
+
+	v := validator.New()
+	data.ValidateMovieTranslation(v, translation)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("put movie translation handler: %s", err))
+		}
+		return
+	}
+
+	created, err := app.models.Translation.Upsert(translation)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("put movie translation handler: %s", err))
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	err = app.writeJSON(w, status, nil, envelope{"translation": translation})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("put movie translation handler: %s", err))
+	}
+}
+
+func (app *application) deleteMovieTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Translation.Delete(id, app.readLanguageParam(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete movie translation handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "translation successfully deleted"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("delete movie translation handler: %s", err))
+	}
+}
+
+func (app *application) readLanguageParam(r *http.Request) string {
+	params := httprouter.ParamsFromContext(r.Context())
+	return data.NormaliseLanguage(params.ByName("language"))
+}
+
+func (app *application) readLanguages(r *http.Request, v *validator.Validator) []string {
+	qs := r.URL.Query()
+	if qs.Has("lang") {
+		languages := []string{}
+		for _, tag := range app.readCSV(qs, "lang", []string{}) {
+			tag = data.NormaliseLanguage(tag)
+			data.ValidateLanguage(v, "lang", tag)
+			if !v.IsValid() {
+				return nil
+			}
+
+			languages = append(languages, tag)
+		}
+		v.CheckError(len(languages) <= maxPreferredLanguages, "lang", fmt.Sprintf("must not contain more than %d languages", maxPreferredLanguages))
+
+		return withBaseLanguages(languages)
+	}
+
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	preferences := []weighted{}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = data.NormaliseLanguage(tag)
+		if !data.LanguageRX.MatchString(tag) {
+			continue
+		}
+
+		q := 1.0
+		if val, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		preferences = append(preferences, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].q > preferences[j].q
+	})
+
+	languages := []string{}
+	for _, p := range preferences {
+		if len(languages) == maxPreferredLanguages {
+			break
+		}
+
+		languages = append(languages, p.tag)
+	}
+
+	return withBaseLanguages(languages)
+}
+
+func withBaseLanguages(languages []string) []string {
+	seen := make(map[string]bool, len(languages))
+	result := make([]string, 0, len(languages))
+	for _, tag := range languages {
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+
+	// "pt-br" falls back to "pt" once every explicitly requested tag has been tried.
+	for _, tag := range languages {
+		base, _, _ := strings.Cut(tag, "-")
+		if !seen[base] {
+			seen[base] = true
+			result = append(result, base)
+		}
+	}
+
+	return result
+}
@@ -4,11 +4,26 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"huytran2000-hcmus/greenlight/internal/data"
 	"huytran2000-hcmus/greenlight/internal/validator"
 )
 
+var movieSortWhiteList = []string{
+	"id",
+	"title",
+	"year",
+	"runtime",
+	"-id",
+	"-title",
+	"-year",
+	"-runtime",
+	"rating",
+	"-rating",
+	"relevance",
+}
+
 func (app *application) createMovieHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Title   string       `json:"title"`
@@ -30,14 +45,33 @@ func (app *application) createMovieHandler(w http.ResponseWriter, r *http.Reques
 		Genres:  input.Genres,
 	}
 
+	genres, err := app.models.Genre.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create movie handler: %s", err))
+		return
+	}
+
 	v := validator.New()
-	data.ValidateMovie(v, movie)
+	strict := app.readBool(r.URL.Query(), "strict", false, v)
+	data.ValidateMovie(v, movie, genres)
 	if !v.IsValid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.models.Movie.Insert(movie)
+	duplicates, err := app.models.Movie.FindDuplicates(movie.Title, movie.Year, data.DefaultDuplicateThreshold)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create movie handler: %s", err))
+		return
+	}
+
+	if strict && len(duplicates) > 0 {
+		v.AddFieldError("title", fmt.Sprintf("a movie with a similar title and the same year already exists (id %d)", duplicates[0].ID))
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Movie.Insert(movie, app.contextGetUser(r).ID)
 	if err != nil {
 		app.serverErrorResponse(w, r, fmt.Errorf("create movie handler: %s", err))
 		return
@@ -45,8 +79,14 @@ func (app *application) createMovieHandler(w http.ResponseWriter, r *http.Reques
 
 	header := http.Header{}
 	header.Set("Location", fmt.Sprintf("/v1/movie/%d", movie.ID))
+	header.Set("ETag", movieETag(movie, false))
 
-	err = app.writeJSON(w, http.StatusCreated, header, envelope{"movie": movie})
+	evlp := envelope{"movie": movie}
+	if len(duplicates) > 0 {
+		evlp["possible_duplicates"] = projectMovies(duplicates, []string{"title", "year"})
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, header, evlp)
 	if err != nil {
 		app.serverErrorResponse(w, r, fmt.Errorf("create movie handler: %s", err))
 	}
@@ -59,7 +99,21 @@ func (app *application) showMovieHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	movie, err := app.models.Movie.Get(id)
+	v := validator.New()
+	qs := r.URL.Query()
+	fields := app.readCSV(qs, "fields", []string{})
+	include := app.readCSV(qs, "include", []string{})
+	languages := app.readLanguages(r, v)
+	data.ValidateMovieFields(v, fields)
+	for _, val := range include {
+		v.CheckError(val == "credits", "include", fmt.Sprintf("unknown include %q", val))
+	}
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	movie, err := app.models.Movie.GetFields(id, fields)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -70,7 +124,40 @@ func (app *application) showMovieHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movie": movie})
+	if len(include) > 0 {
+		movie.Credits, err = app.models.Credit.GetAllForMovie(movie.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, fmt.Errorf("show movie handler: %s", err))
+			return
+		}
+	}
+
+	localised := []data.Movie{*movie}
+	err = app.models.Translation.Localise(localised, languages)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("show movie handler: %s", err))
+		return
+	}
+	movie = &localised[0]
+	w.Header().Add("Vary", "Accept-Language")
+
+	// The version-based ETag does not cover credits or translations.
+	if len(include) > 0 || movie.Language != "" {
+		err = app.writeJSONWithWeakETag(w, r, nil, envelope{"movie": projectMovie(*movie, fields)})
+		if err != nil {
+			app.serverErrorResponse(w, r, fmt.Errorf("show movie handler: %s", err))
+		}
+		return
+	}
+
+	header := http.Header{}
+	header.Set("ETag", movieETag(movie, len(fields) > 0))
+	if etagMatches(r.Header.Get("If-None-Match"), header.Get("ETag"), true) {
+		app.notModifiedResponse(w, header)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, header, envelope{"movie": projectMovie(*movie, fields)})
 	if err != nil {
 		app.serverErrorResponse(w, r, fmt.Errorf("show movie handler: %s", err))
 		return
@@ -78,50 +165,138 @@ func (app *application) showMovieHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (app *application) listAllMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("ids") {
+		app.batchGetMoviesHandler(w, r)
+		return
+	}
+
 	var input struct {
 		data.Filters
-		Title  string
-		Genres []string
+		data.MovieFilter
+		Facets []string
+		Fields []string
 	}
 
 	v := validator.New()
 	qs := r.URL.Query()
 
-	input.Title = app.readString(qs, "title", "")
-	input.Genres = app.readCSV(qs, "genres", []string{})
+	input.MovieFilter = app.readMovieFilter(qs, v)
+	input.Facets = app.readCSV(qs, "facets", []string{})
+	input.Fields = app.readCSV(qs, "fields", []string{})
 	input.Page = app.readInt(qs, "page", 1, v)
 	input.PageSize = app.readInt(qs, "page_size", 20, v)
 	input.Sort = app.readString(qs, "sort", "id")
-	input.SortWhiteList = []string{
-		"id",
-		"title",
-		"year",
-		"runtime",
-		"-id",
-		"-title",
-		"-year",
-		"-runtime",
+	input.CursorMode = qs.Has("cursor")
+	input.Cursor = app.readString(qs, "cursor", "")
+	input.SortWhiteList = movieSortWhiteList
+	languages := app.readLanguages(r, v)
+
+	if qs.Has("in_watchlist") {
+		user := app.contextGetUser(r)
+		if user.IsAnonymous() {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		inWatchlist := app.readBool(qs, "in_watchlist", false, v)
+		input.InWatchlist = &inWatchlist
+		input.UserID = user.ID
 	}
 
 	data.ValidateFilter(v, input.Filters)
+	data.ValidateMovieFilter(v, input.MovieFilter)
+	v.CheckError(input.Sort != "relevance" || input.Title != "", "sort", "relevance sort requires a title search")
+	v.CheckError(input.Sort != "relevance" || !input.CursorMode, "cursor", "cannot be used with relevance sort")
+	data.ValidateFacets(v, input.Facets)
+	data.ValidateMovieFields(v, input.Fields)
 	if !v.IsValid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	movies, metadata, err := app.models.Movie.GetAll(input.Title, input.Genres, input.Filters)
+	movies, metadata, err := app.models.Movie.GetAll(input.MovieFilter, input.Fields, input.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, fmt.Errorf("list all movies handler: %s", err))
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movies": movies, "metadata": metadata})
+	err = app.models.Translation.Localise(movies, languages)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list all movies handler: %s", err))
+		return
+	}
+	w.Header().Add("Vary", "Accept-Language")
+
+	evlp := envelope{"movies": projectMovies(movies, input.Fields), "metadata": metadata}
+	if len(input.Facets) > 0 {
+		facets, err := app.models.Movie.GetFacets(input.MovieFilter, input.Facets)
+		if err != nil {
+			app.serverErrorResponse(w, r, fmt.Errorf("list all movies handler: %s", err))
+			return
+		}
+
+		evlp["facets"] = facets
+	}
+
+	err = app.writeJSONWithWeakETag(w, r, nil, evlp)
 	if err != nil {
 		app.serverErrorResponse(w, r, fmt.Errorf("list all movies handler: %s", err))
 		return
 	}
 }
 
+func (app *application) batchGetMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	ids := app.readIDs(qs, "ids", v)
+	fields := app.readCSV(qs, "fields", []string{})
+	languages := app.readLanguages(r, v)
+	if v.IsValid() {
+		data.ValidateMovieIDs(v, ids, app.cfg.movies.batchMax)
+	}
+	data.ValidateMovieFields(v, fields)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	found, err := app.models.Movie.GetByIDs(ids, fields)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("batch get movies handler: %s", err))
+		return
+	}
+
+	byID := make(map[int64]data.Movie, len(found))
+	for _, movie := range found {
+		byID[movie.ID] = movie
+	}
+
+	movies := make([]data.Movie, 0, len(ids))
+	missing := []int64{}
+	for _, id := range ids {
+		movie, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+
+		movies = append(movies, movie)
+	}
+
+	err = app.models.Translation.Localise(movies, languages)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("batch get movies handler: %s", err))
+		return
+	}
+	w.Header().Add("Vary", "Accept-Language")
+
+	err = app.writeJSONWithWeakETag(w, r, nil, envelope{"movies": projectMovies(movies, fields), "missing": missing})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("batch get movies handler: %s", err))
+	}
+}
+
 func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := app.readIDParam(r)
 	if err != nil {
@@ -140,6 +315,72 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch != "" && !movieIfMatch(ifMatch, movie) {
+		app.preconditionFailedResponse(w, r)
+		return
+	}
+
+	patchType := patchMediaType(r)
+	if patchType != "" {
+		errs, err := app.applyMoviePatch(w, r, patchType, movie)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		if errs != nil {
+			app.failedValidationResponse(w, r, errs)
+			return
+		}
+	} else {
+		err = app.applyMovieInput(w, r, movie)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	genres, err := app.models.Genre.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("update movie handler: %s", err))
+		return
+	}
+
+	v := validator.New()
+	data.ValidateMovie(v, movie, genres)
+	if !v.IsValid() {
+		if patchType != "" {
+			app.failedValidationResponse(w, r, pointerErrors(v.Errors))
+			return
+		}
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Movie.Update(movie, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict) && ifMatch != "":
+			app.preconditionFailedResponse(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update movie handler: %s", err))
+		}
+		return
+	}
+
+	header := http.Header{}
+	header.Set("ETag", movieETag(movie, false))
+
+	err = app.writeJSON(w, http.StatusOK, header, envelope{"movie": movie})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("update movie handler: %s", err))
+	}
+}
+
+func (app *application) applyMovieInput(w http.ResponseWriter, r *http.Request, movie *data.Movie) error {
 	var input struct {
 		Title   *string       `json:"title"`
 		Year    *int32        `json:"year"`
@@ -147,10 +388,9 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 		Genres  []string      `json:"genres"`
 	}
 
-	err = app.readJSON(w, r, &input)
+	err := app.readJSON(w, r, &input)
 	if err != nil {
-		app.badRequestResponse(w, r, err)
-		return
+		return err
 	}
 
 	if input.Title != nil {
@@ -168,49 +408,193 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 	if input.Genres != nil {
 		movie.Genres = input.Genres
 	}
+
+	return nil
+}
+
+func (app *application) deleteMovieHanlder(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch != "" {
+		app.deleteMovieIfMatch(w, r, id, ifMatch)
+		return
+	}
+
+	err = app.models.Movie.Delete(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete movie handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "movie succesfully moved to trash"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("delete movie handler: %s", err))
+	}
+}
+
+func (app *application) deleteMovieIfMatch(w http.ResponseWriter, r *http.Request, id int64, ifMatch string) {
+	movie, err := app.models.Movie.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete movie handler: %s", err))
+		}
+		return
+	}
+
+	if !movieIfMatch(ifMatch, movie) {
+		app.preconditionFailedResponse(w, r)
+		return
+	}
+
+	err = app.models.Movie.DeleteVersion(id, movie.Version, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.preconditionFailedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete movie handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "movie succesfully moved to trash"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("delete movie handler: %s", err))
+	}
+}
+
+func (app *application) listTrashedMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
 	v := validator.New()
-	data.ValidateMovie(v, movie)
+	qs := r.URL.Query()
+
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "-deleted_at")
+	input.SortWhiteList = []string{
+		"id",
+		"title",
+		"deleted_at",
+		"-id",
+		"-title",
+		"-deleted_at",
+	}
+
+	data.ValidateFilter(v, input.Filters)
 	if !v.IsValid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.models.Movie.Update(movie)
+	movies, metadata, err := app.models.Movie.GetAllDeleted(input.Filters)
 	if err != nil {
-		if errors.Is(err, data.ErrEditConflict) {
-			app.editConflictResponse(w, r)
-			return
-		}
-		app.serverErrorResponse(w, r, fmt.Errorf("update movie handler: %s", err))
+		app.serverErrorResponse(w, r, fmt.Errorf("list trashed movies handler: %s", err))
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movie": movie})
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movies": movies, "metadata": metadata})
 	if err != nil {
-		app.serverErrorResponse(w, r, fmt.Errorf("update movie handler: %s", err))
+		app.serverErrorResponse(w, r, fmt.Errorf("list trashed movies handler: %s", err))
 	}
 }
 
-func (app *application) deleteMovieHanlder(w http.ResponseWriter, r *http.Request) {
+func (app *application) restoreMovieHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := app.readIDParam(r)
 	if err != nil {
 		app.notFoundResponse(w, r)
 		return
 	}
 
-	err = app.models.Movie.Delete(id)
+	movie, err := app.models.Movie.Restore(id, app.contextGetUser(r).ID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
 		default:
-			app.serverErrorResponse(w, r, fmt.Errorf("delete movie handler: %s", err))
+			app.serverErrorResponse(w, r, fmt.Errorf("restore movie handler: %s", err))
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "movie succesfully deleted"})
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"movie": movie})
 	if err != nil {
-		app.serverErrorResponse(w, r, fmt.Errorf("delete movie handler: %s", err))
+		app.serverErrorResponse(w, r, fmt.Errorf("restore movie handler: %s", err))
+	}
+}
+
+func (app *application) suggestMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	prefix := app.readString(qs, "q", "")
+	limit := app.readInt(qs, "limit", 10, v)
+
+	data.ValidateSuggestQuery(v, prefix, limit)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	suggestions, err := app.models.Movie.Suggest(prefix, limit)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("suggest movies handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"suggestions": suggestions})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("suggest movies handler: %s", err))
+	}
+}
+
+func projectMovie(movie data.Movie, fields []string) any {
+	if len(fields) == 0 {
+		return movie
+	}
+
+	return movie.Project(fields)
+}
+
+func projectMovies(movies []data.Movie, fields []string) any {
+	if len(fields) == 0 {
+		return movies
+	}
+
+	projections := make([]map[string]any, 0, len(movies))
+	for _, movie := range movies {
+		projections = append(projections, movie.Project(fields))
+	}
+
+	return projections
+}
+
+func (app *application) readMovieFilter(qs url.Values, v *validator.Validator) data.MovieFilter {
+	return data.MovieFilter{
+		Title:         app.readString(qs, "title", ""),
+		Genres:        app.readCSV(qs, "genres", []string{}),
+		GenresAny:     app.readCSV(qs, "genres_any", []string{}),
+		GenresNot:     app.readCSV(qs, "genres_not", []string{}),
+		YearMin:       app.readInt(qs, "year_min", 0, v),
+		YearMax:       app.readInt(qs, "year_max", 0, v),
+		RuntimeMin:    app.readInt(qs, "runtime_min", 0, v),
+		RuntimeMax:    app.readInt(qs, "runtime_max", 0, v),
+		CreatedAfter:  app.readTime(qs, "created_after", v),
+		CreatedBefore: app.readTime(qs, "created_before", v),
 	}
 }
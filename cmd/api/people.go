@@ -0,0 +1,358 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) createPersonHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name      string `json:"name"`
+		BirthDate string `json:"birth_date"`
+		Biography string `json:"biography"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	person := &data.Person{
+		Name:      input.Name,
+		BirthDate: readDate(input.BirthDate, "birth_date", v),
+		Biography: input.Biography,
+	}
+
+	data.ValidatePerson(v, person)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Person.Insert(person)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create person handler: %s", err))
+		return
+	}
+
+	header := http.Header{}
+	header.Set("Location", fmt.Sprintf("/v1/people/%d", person.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, header, envelope{"person": person})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create person handler: %s", err))
+	}
+}
+
+func (app *application) showPersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	person, err := app.models.Person.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("show person handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"person": person})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("show person handler: %s", err))
+	}
+}
+
+func (app *application) listPeopleHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Name = app.readString(qs, "name", "")
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "id")
+	input.SortWhiteList = []string{"id", "name", "birth_date", "-id", "-name", "-birth_date"}
+
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	people, metadata, err := app.models.Person.GetAll(input.Name, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list people handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"people": people, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list people handler: %s", err))
+	}
+}
+
+func (app *application) updatePersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	person, err := app.models.Person.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update person handler: %s", err))
+		}
+		return
+	}
+
+	var input struct {
+		Name      *string `json:"name"`
+		BirthDate *string `json:"birth_date"`
+		Biography *string `json:"biography"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	if input.Name != nil {
+		person.Name = *input.Name
+	}
+
+	if input.BirthDate != nil {
+		person.BirthDate = readDate(*input.BirthDate, "birth_date", v)
+	}
+
+	if input.Biography != nil {
+		person.Biography = *input.Biography
+	}
+
+	data.ValidatePerson(v, person)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Person.Update(person)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update person handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"person": person})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("update person handler: %s", err))
+	}
+}
+
+func (app *application) deletePersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Person.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete person handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "person successfully deleted"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("delete person handler: %s", err))
+	}
+}
+
+func (app *application) showFilmographyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "-year")
+	input.SortWhiteList = []string{"year", "title", "-year", "-title"}
+
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	person, err := app.models.Person.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("show filmography handler: %s", err))
+		}
+		return
+	}
+
+	credits, metadata, err := app.models.Credit.GetAllForPerson(person.ID, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("show filmography handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"person": person, "filmography": credits, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("show filmography handler: %s", err))
+	}
+}
+
+func (app *application) createMovieCreditHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		PersonID     int64  `json:"person_id"`
+		Role         string `json:"role"`
+		Character    string `json:"character"`
+		BillingOrder int32  `json:"billing_order"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	credit := &data.MovieCredit{
+		MovieID:      id,
+		PersonID:     input.PersonID,
+		Role:         input.Role,
+		Character:    input.Character,
+		BillingOrder: input.BillingOrder,
+	}
+
+	v := validator.New()
+	data.ValidateMovieCredit(v, credit)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("create movie credit handler: %s", err))
+		}
+		return
+	}
+
+	err = app.models.Credit.Insert(credit)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidPerson):
+			v.AddFieldError("person_id", "person does not exist")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrDuplicateCredit):
+			v.AddFieldError("person_id", "person is already credited with this role")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("create movie credit handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, nil, envelope{"credit": credit})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create movie credit handler: %s", err))
+	}
+}
+
+func (app *application) deleteMovieCreditHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	creditID, err := app.readCreditIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Credit.Delete(id, creditID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete movie credit handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "credit successfully deleted"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("delete movie credit handler: %s", err))
+	}
+}
+
+func readDate(raw, key string, v *validator.Validator) *time.Time {
+	if raw == "" {
+		return nil
+	}
+
+	date, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		v.AddFieldError(key, "must be a date in YYYY-MM-DD format")
+		return nil
+	}
+
+	return &date
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) listMovieReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "-created_at")
+	input.SortWhiteList = []string{"created_at", "score", "-created_at", "-score"}
+
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("list movie reviews handler: %s", err))
+		}
+		return
+	}
+
+	reviews, metadata, err := app.models.Review.GetAllForMovie(id, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list movie reviews handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"reviews": reviews, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list movie reviews handler: %s", err))
+	}
+}
+
+func (app *application) createMovieReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Score int32  `json:"score"`
+		Body  string `json:"body"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	review := &data.Review{
+		MovieID: id,
+		UserID:  app.contextGetUser(r).ID,
+		Score:   input.Score,
+		Body:    input.Body,
+	}
+
+	v := validator.New()
+	data.ValidateReview(v, review)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Movie.GetFields(id, []string{"id"})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("create movie review handler: %s", err))
+		}
+		return
+	}
+
+	err = app.models.Review.Insert(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateReview):
+			v.AddFieldError("review", "you have already reviewed this movie, use PUT to update it")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("create movie review handler: %s", err))
+		}
+		return
+	}
+
+	header := make(http.Header)
+	header.Set("Location", fmt.Sprintf("/v1/movies/%d/reviews", id))
+
+	err = app.writeJSON(w, http.StatusCreated, header, envelope{"review": review})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("create movie review handler: %s", err))
+	}
+}
+
+func (app *application) updateMovieReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	review, err := app.models.Review.Get(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update movie review handler: %s", err))
+		}
+		return
+	}
+
+	var input struct {
+		Score int32  `json:"score"`
+		Body  string `json:"body"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	review.Score = input.Score
+	review.Body = input.Body
+
+	v := validator.New()
+	data.ValidateReview(v, review)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Review.Update(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("update movie review handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"review": review})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("update movie review handler: %s", err))
+	}
+}
+
+func (app *application) deleteMovieReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Review.Delete(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("delete movie review handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "review successfully deleted"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("delete movie review handler: %s", err))
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"expvar"
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -15,19 +16,92 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", app.healthcheckHandler)
 
 	router.HandlerFunc(http.MethodGet, "/v1/movies", app.requirePermission("movies:read", app.listAllMoviesHandler))
-	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.requirePermission("movies:read", app.showMovieHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.dispatchIDParam(
+		map[string]http.HandlerFunc{
+			"suggest":    app.requirePermission("movies:read", app.suggestMoviesHandler),
+			"export":     app.requirePermission("movies:read", app.exportMoviesHandler),
+			"trash":      app.requirePermission("movies:write", app.listTrashedMoviesHandler),
+			"duplicates": app.requirePermission("movies:write", app.listDuplicateMoviesHandler),
+			"stats":      app.requirePermission("movies:stats", app.movieStatsHandler),
+		},
+		app.requirePermission("movies:read", app.showMovieHandler),
+	))
 	router.HandlerFunc(http.MethodPost, "/v1/movies", app.requirePermission("movies:write", app.createMovieHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id", app.dispatchIDParam(
+		map[string]http.HandlerFunc{
+			"import": app.requirePermission("movies:write", app.importMoviesHandler),
+		},
+		app.notFoundResponse,
+	))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/merge", app.requirePermission("movies:write", app.mergeMovieHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/restore", app.requirePermission("movies:write", app.restoreMovieHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions", app.requirePermission("movies:read", app.listMovieRevisionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions/:version", app.requirePermission("movies:read", app.showMovieRevisionHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/revisions/:version/restore", app.requirePermission("movies:write", app.restoreMovieRevisionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.listMovieReviewsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.createMovieReviewHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.updateMovieReviewHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.deleteMovieReviewHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/similar", app.requirePermission("movies:read", app.listSimilarMoviesHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/translations", app.requirePermission("movies:read", app.listMovieTranslationsHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/translations/:language", app.requirePermission("movies:write", app.putMovieTranslationHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/translations/:language", app.requirePermission("movies:write", app.deleteMovieTranslationHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/poster", app.requirePermission("movies:write", app.uploadMoviePosterHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/backdrop", app.requirePermission("movies:write", app.uploadMovieBackdropHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/credits", app.requirePermission("people:write", app.createMovieCreditHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/credits/:credit_id", app.requirePermission("people:write", app.deleteMovieCreditHandler))
 	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id", app.requirePermission("movies:write", app.updateMovieHandler))
 	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id", app.requirePermission("movies:write", app.deleteMovieHanlder))
 
+	router.HandlerFunc(http.MethodGet, "/v1/collections", app.requirePermission("movies:read", app.listCollectionsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/collections", app.requirePermission("movies:read", app.createCollectionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/collections/:id", app.requirePermission("movies:read", app.showCollectionHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/collections/:id", app.requirePermission("movies:read", app.updateCollectionHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/collections/:id", app.requirePermission("movies:read", app.deleteCollectionHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/genres", app.requirePermission("movies:read", app.listGenresHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/genres", app.requirePermission("genres:write", app.createGenreHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/genres/:id", app.requirePermission("genres:write", app.updateGenreHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/genres/:id/merge", app.requirePermission("genres:write", app.mergeGenreHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/people", app.requirePermission("people:read", app.listPeopleHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/people", app.requirePermission("people:write", app.createPersonHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/people/:id", app.requirePermission("people:read", app.showPersonHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/people/:id/filmography", app.requirePermission("people:read", app.showFilmographyHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/people/:id", app.requirePermission("people:write", app.updatePersonHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/people/:id", app.requirePermission("people:write", app.deletePersonHandler))
+
 	router.HandlerFunc(http.MethodPost, "/v1/users", app.registerUserHandler)
 	router.HandlerFunc(http.MethodPut, "/v1/users/activated", app.activateUserHandler)
 	router.HandlerFunc(http.MethodPut, "/v1/users/password", app.updateUserPasswordHandler)
 
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/watchlist", app.requireActivatedUser(app.listWatchlistHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/users/me/watchlist", app.requireActivatedUser(app.addToWatchlistHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/me/watchlist/:id", app.requireActivatedUser(app.moveWatchlistItemHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/watchlist/:id", app.requireActivatedUser(app.removeFromWatchlistHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/watched", app.requireActivatedUser(app.listWatchedHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/users/me/watched", app.requireActivatedUser(app.markWatchedHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/watched/:id", app.requireActivatedUser(app.unmarkWatchedHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/recommendations", app.requirePermission("movies:read", app.listRecommendationsHandler))
+
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", app.createAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/authentication", app.requireAuthenticatedUser(app.deleteAuthenticationTokenHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/authentication/all", app.requireAuthenticatedUser(app.deleteAllAuthenticationTokensHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/refresh", app.refreshAuthenticationTokenHandler)
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/password-reset", app.createPasswordResetTokenHandler)
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/activation", app.createActivationTokenHandler)
 
 	router.Handler(http.MethodGet, "/v1/debug/vars", expvar.Handler())
-	return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
+
+	if files, ok := app.storage.(http.Handler); ok && strings.HasPrefix(app.cfg.storage.baseURL, "/") {
+		prefix := strings.TrimSuffix(app.cfg.storage.baseURL, "/")
+		router.Handler(http.MethodGet, prefix+"/*filepath", http.StripPrefix(prefix, files))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", app.rateLimit(app.authenticate(router)))
+	mux.Handle("/v1/movies/suggest", app.suggestRateLimit(app.authenticate(router)))
+
+	return app.metrics(app.recoverPanic(app.enableCORS(mux)))
 }
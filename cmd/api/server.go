@@ -22,6 +22,9 @@ func (app *application) serve() error {
 		ErrorLog:     log.New(app.logger, "", 0),
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
 	shutDownErr := make(chan error)
 	go func() {
 		quit := make(chan os.Signal, 1)
@@ -43,10 +46,17 @@ func (app *application) serve() error {
 			"env":  app.cfg.env,
 		})
 
+		stopJobs()
 		app.wg.Wait()
 		shutDownErr <- nil
 	}()
 
+	if app.cfg.movies.trashRetention > 0 && app.cfg.movies.purgeInterval > 0 {
+		app.background(func() {
+			app.purgeDeletedMovies(jobsCtx)
+		})
+	}
+
 	app.logger.Info("starting server", map[string]string{
 		"addr": srv.Addr,
 		"env":  app.cfg.env,
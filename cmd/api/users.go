@@ -4,15 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/tomasen/realip"
+
 	"huytran2000-hcmus/greenlight/internal/data"
 	"huytran2000-hcmus/greenlight/internal/validator"
 )
 
 const (
 	defaultActivationTimeout     = 3 * 24 * time.Hour
-	defaultAuthenticationTimeout = 3 * time.Hour
+	defaultAuthenticationTimeout = 15 * time.Minute
+	defaultRefreshTimeout        = 30 * 24 * time.Hour
 	defaultPasswordResetTimeout  = 60 * time.Minute
 )
 
@@ -188,13 +192,93 @@ func (app *application) createAuthenticationTokenHandler(w http.ResponseWriter,
 		return
 	}
 
-	token, err := app.models.Token.New(data.ScopeAuthentication, user.ID, defaultAuthenticationTimeout)
+	access, refresh, err := app.models.Token.NewPair(user.ID, defaultAuthenticationTimeout, defaultRefreshTimeout)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, nil, envelope{"authentication": access, "refresh": refresh})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) refreshAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token string `json:"token"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateTokenPlainText(v, input.Token)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	access, refresh, err := app.models.Token.Rotate(input.Token, defaultAuthenticationTimeout, defaultRefreshTimeout)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrTokenReused):
+			app.logger.Info("refresh token reused, token family revoked", map[string]string{
+				"ip": realip.FromRequest(r),
+			})
+			v.AddFieldError("token", "invalid or expired refresh token")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddFieldError("token", "invalid or expired refresh token")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, nil, envelope{"authentication": access, "refresh": refresh})
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	// requireAuthenticatedUser has already checked the header is a valid bearer token.
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	err := app.models.Token.DeleteForToken(data.ScopeAuthentication, token)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidAuthenticationTokenResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, nil, envelope{"authentication": token})
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "you have been successfully logged out"})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteAllAuthenticationTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	err := app.models.Token.DeleteAllSessionsForUser(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "all sessions have been successfully logged out"})
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -332,6 +416,12 @@ func (app *application) updateUserPasswordHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	err = app.models.Token.DeleteAllSessionsForUser(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "password has been successfully reset"})
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
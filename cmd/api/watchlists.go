@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/data"
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+func (app *application) listWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "position")
+	input.SortWhiteList = []string{"position", "added_at", "title", "-position", "-added_at", "-title"}
+
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	items, metadata, err := app.models.Watchlist.GetAll(app.contextGetUser(r).ID, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list watchlist handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"watchlist": items, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list watchlist handler: %s", err))
+	}
+}
+
+func (app *application) addToWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MovieID int64 `json:"movie_id"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.CheckError(input.MovieID > 0, "movie_id", "must be provided")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	movie, err := app.models.Movie.Get(input.MovieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddFieldError("movie_id", "movie does not exist")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("add to watchlist handler: %s", err))
+		}
+		return
+	}
+
+	item, err := app.models.Watchlist.Add(app.contextGetUser(r).ID, movie.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateWatchlistItem):
+			v.AddFieldError("movie_id", "movie is already in your watchlist")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("add to watchlist handler: %s", err))
+		}
+		return
+	}
+	item.Movie = *movie
+
+	err = app.writeJSON(w, http.StatusCreated, nil, envelope{"item": item})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("add to watchlist handler: %s", err))
+	}
+}
+
+func (app *application) moveWatchlistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Position int32 `json:"position"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateWatchlistPosition(v, input.Position)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	item, err := app.models.Watchlist.Move(app.contextGetUser(r).ID, id, input.Position)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("move watchlist item handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"item": item})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("move watchlist item handler: %s", err))
+	}
+}
+
+func (app *application) removeFromWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Watchlist.Remove(app.contextGetUser(r).ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("remove from watchlist handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "movie successfully removed from watchlist"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("remove from watchlist handler: %s", err))
+	}
+}
+
+func (app *application) listWatchedHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Sort = app.readString(qs, "sort", "-watched_on")
+	input.SortWhiteList = []string{"watched_on", "title", "-watched_on", "-title"}
+
+	data.ValidateFilter(v, input.Filters)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	watched, metadata, err := app.models.Watched.GetAll(app.contextGetUser(r).ID, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list watched handler: %s", err))
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"watched": watched, "metadata": metadata})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("list watched handler: %s", err))
+	}
+}
+
+func (app *application) markWatchedHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MovieID   int64  `json:"movie_id"`
+		WatchedOn string `json:"watched_on"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.CheckError(input.MovieID > 0, "movie_id", "must be provided")
+
+	watchedOn := time.Now().Truncate(24 * time.Hour)
+	if input.WatchedOn != "" {
+		watchedOn, err = time.Parse(time.DateOnly, input.WatchedOn)
+		v.CheckError(err == nil, "watched_on", "must be a date in YYYY-MM-DD format")
+	}
+	if v.IsValid() {
+		data.ValidateWatchedOn(v, watchedOn)
+	}
+	if !v.IsValid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	movie, err := app.models.Movie.Get(input.MovieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddFieldError("movie_id", "movie does not exist")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("mark watched handler: %s", err))
+		}
+		return
+	}
+
+	watched, err := app.models.Watched.Add(app.contextGetUser(r).ID, movie.ID, watchedOn)
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("mark watched handler: %s", err))
+		return
+	}
+	watched.Movie = *movie
+
+	err = app.writeJSON(w, http.StatusCreated, nil, envelope{"watched": watched})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("mark watched handler: %s", err))
+	}
+}
+
+func (app *application) unmarkWatchedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Watched.Remove(app.contextGetUser(r).ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, fmt.Errorf("unmark watched handler: %s", err))
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, nil, envelope{"message": "movie successfully removed from watched history"})
+	if err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("unmark watched handler: %s", err))
+	}
+}
@@ -8,6 +8,7 @@ require github.com/lib/pq v1.10.9
 
 require (
 	golang.org/x/crypto v0.9.0
+	golang.org/x/image v0.18.0
 	golang.org/x/time v0.3.0
 )
 
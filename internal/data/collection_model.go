@@ -0,0 +1,283 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type CollectionModel struct {
+	DB *sql.DB
+}
+
+func (m CollectionModel) Insert(collection *Collection) error {
+	query := `
+    INSERT INTO collections (user_id, name, description, visibility)
+    VALUES ($1, $2, $3, $4)
+    RETURNING id, created_at, updated_at, version`
+
+	args := []any{collection.OwnerID, collection.Name, collection.Description, collection.Visibility}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("data: begin collection transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, args...).
+		Scan(&collection.ID, &collection.CreatedAt, &collection.UpdatedAt, &collection.Version)
+	if err != nil {
+		return fmt.Errorf("data: insert a collection: %s", err)
+	}
+
+	err = replaceCollectionMovies(ctx, tx, collection.ID, collection.MovieIDs)
+	if err != nil {
+		return err
+	}
+	collection.MovieCount = len(collection.MovieIDs)
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("data: commit collection transaction: %s", err)
+	}
+
+	return nil
+}
+
+func (m CollectionModel) Get(id int64) (*Collection, error) {
+	if id <= 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+    SELECT id, user_id, name, description, visibility, created_at, updated_at, version,
+        ARRAY(SELECT movie_id FROM collection_movies WHERE collection_id = collections.id ORDER BY position)
+    FROM collections
+    WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var collection Collection
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&collection.ID,
+		&collection.OwnerID,
+		&collection.Name,
+		&collection.Description,
+		&collection.Visibility,
+		&collection.CreatedAt,
+		&collection.UpdatedAt,
+		&collection.Version,
+		pq.Array(&collection.MovieIDs),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, fmt.Errorf("data: query a collection: %s", err)
+		}
+	}
+	collection.MovieCount = len(collection.MovieIDs)
+
+	return &collection, nil
+}
+
+func (m CollectionModel) GetMovies(id int64) ([]Movie, error) {
+	query := fmt.Sprintf(`
+    SELECT %s
+    FROM collection_movies
+    INNER JOIN movies ON movies.id = collection_movies.movie_id
+    WHERE collection_id = $1 AND deleted_at IS NULL
+    ORDER BY position`, strings.Join(MovieFields, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("data: query movies of collection with id=%d: %s", id, err)
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		var mv Movie
+		err = rows.Scan(mv.scanDests(MovieFields)...)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate movies of collection with id=%d: %s", id, err)
+	}
+
+	return movies, nil
+}
+
+func (m CollectionModel) Update(collection *Collection) error {
+	query := `
+    UPDATE collections
+    SET name = $1, description = $2, visibility = $3, updated_at = NOW(), version = version + 1
+    WHERE id = $4 AND version = $5
+    RETURNING updated_at, version`
+
+	args := []any{
+		collection.Name,
+		collection.Description,
+		collection.Visibility,
+		collection.ID,
+		collection.Version,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("data: begin collection transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&collection.UpdatedAt, &collection.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return fmt.Errorf("data: update a collection: %s", err)
+		}
+	}
+
+	err = replaceCollectionMovies(ctx, tx, collection.ID, collection.MovieIDs)
+	if err != nil {
+		return err
+	}
+	collection.MovieCount = len(collection.MovieIDs)
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("data: commit collection transaction: %s", err)
+	}
+
+	return nil
+}
+
+func (m CollectionModel) Delete(id int64) error {
+	stmt := `
+    DELETE FROM collections
+    WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("data: delete a collection: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m CollectionModel) GetAll(viewerID int64, mine bool, name string, filter Filters) ([]Collection, Metadata, error) {
+	args := queryArgs{}
+	cond := fmt.Sprintf("(visibility = 'public' OR user_id = %s)", args.bind(viewerID))
+	if mine {
+		cond = fmt.Sprintf("user_id = %s", args.bind(viewerID))
+	}
+
+	if name != "" {
+		cond += fmt.Sprintf(" AND name ILIKE '%%' || %s || '%%'", args.bind(likeEscaper.Replace(name)))
+	}
+
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), id, user_id, name, description, visibility, created_at, updated_at, version,
+        (SELECT COUNT(*) FROM collection_movies WHERE collection_id = collections.id)
+    FROM collections
+    WHERE %s
+    ORDER BY %s %s, id ASC
+    LIMIT %s OFFSET %s`,
+		cond,
+		filter.sortColumn(),
+		filter.sortDirection(),
+		args.bind(filter.limit()),
+		args.bind(filter.offset()),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query all collections: %s", err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	collections := []Collection{}
+	for rows.Next() {
+		var collection Collection
+		err = rows.Scan(
+			&totalRecords,
+			&collection.ID,
+			&collection.OwnerID,
+			&collection.Name,
+			&collection.Description,
+			&collection.Visibility,
+			&collection.CreatedAt,
+			&collection.UpdatedAt,
+			&collection.Version,
+			&collection.MovieCount,
+		)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a collection: %s", err)
+		}
+
+		collections = append(collections, collection)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate all collections: %s", err)
+	}
+
+	return collections, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
+
+func replaceCollectionMovies(ctx context.Context, tx *sql.Tx, collectionID int64, movieIDs []int64) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM collection_movies WHERE collection_id = $1`, collectionID)
+	if err != nil {
+		return fmt.Errorf("data: clear collection movies: %s", err)
+	}
+
+	stmt := `
+    INSERT INTO collection_movies (collection_id, movie_id, position)
+    SELECT $1, ids.movie_id, ids.position
+    FROM unnest($2::bigint[]) WITH ORDINALITY AS ids(movie_id, position)`
+
+	_, err = tx.ExecContext(ctx, stmt, collectionID, pq.Array(movieIDs))
+	if err != nil {
+		return fmt.Errorf("data: insert collection movies: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package data
+
+import (
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+type Collection struct {
+	ID          int64     `json:"id"`
+	OwnerID     int64     `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Visibility  string    `json:"visibility"`
+	MovieIDs    []int64   `json:"-"`
+	MovieCount  int       `json:"movie_count"`
+	Movies      []Movie   `json:"movies,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int32     `json:"version"`
+}
+
+func (c *Collection) VisibleTo(user *User) bool {
+	return c.Visibility == VisibilityPublic || (!user.IsAnonymous() && c.OwnerID == user.ID)
+}
+
+func ValidateCollection(v *validator.Validator, c *Collection) {
+	v.CheckError(validator.NotBlank(c.Name), "name", "must be provided")
+	v.CheckError(validator.LengthLessOrEqual(c.Name, 200), "name", "must not be greater than 200 characters")
+	v.CheckError(validator.LengthLessOrEqual(c.Description, 2000), "description", "must not be greater than 2000 characters")
+	v.CheckError(
+		validator.PermittedValue(c.Visibility, VisibilityPublic, VisibilityPrivate),
+		"visibility",
+		"must be either public or private",
+	)
+
+	v.CheckError(len(c.MovieIDs) <= 500, "movie_ids", "must not contain more than 500 movies")
+	v.CheckError(validator.Unique(c.MovieIDs), "movie_ids", "must not contain duplicate values")
+	for _, id := range c.MovieIDs {
+		v.CheckError(id > 0, "movie_ids", "must only contain positive integers")
+	}
+}
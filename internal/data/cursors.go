@@ -0,0 +1,112 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type cursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	ID       int64  `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func (c cursor) encode() string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	err = json.Unmarshal(b, &c)
+	if err != nil || c.ID <= 0 {
+		return c, ErrInvalidCursor
+	}
+
+	if !validCursorValue(strings.TrimPrefix(c.Sort, "-"), c.Value) {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func validCursorValue(column, value string) bool {
+	switch column {
+	case "title":
+		return true
+	case "id":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "year", "runtime":
+		_, err := strconv.ParseInt(value, 10, 32)
+		return err == nil
+	case "rating":
+		f, err := strconv.ParseFloat(value, 64)
+		return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	default:
+		return false
+	}
+}
+
+func movieCursor(mv Movie, filter Filters, backward bool) string {
+	c := cursor{
+		Sort:     filter.Sort,
+		ID:       mv.ID,
+		Backward: backward,
+	}
+
+	switch filter.sortColumn() {
+	case "title":
+		c.Value = mv.Title
+	case "year":
+		c.Value = strconv.FormatInt(int64(mv.Year), 10)
+	case "runtime":
+		c.Value = strconv.FormatInt(int64(mv.Runtime), 10)
+	case "rating":
+		c.Value = strconv.FormatFloat(mv.AverageRating, 'f', 2, 64)
+	default:
+		c.Value = strconv.FormatInt(mv.ID, 10)
+	}
+
+	return c.encode()
+}
+
+func (c cursor) keysetCondition(column, direction string, args *queryArgs) string {
+	op, idOp := ">", ">"
+	if direction == "DESC" {
+		op = "<"
+	}
+
+	if c.Backward {
+		op, idOp = flipComparison(op), flipComparison(idOp)
+	}
+
+	return fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[4]s %[5]s))",
+		column, op, args.bind(c.Value), idOp, args.bind(c.ID))
+}
+
+func flipComparison(op string) string {
+	if op == ">" {
+		return "<"
+	}
+
+	return ">"
+}
@@ -0,0 +1,19 @@
+package data
+
+import "huytran2000-hcmus/greenlight/internal/validator"
+
+var movieFacets = []string{"genres", "year"}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type Facets map[string][]FacetCount
+
+func ValidateFacets(v *validator.Validator, facets []string) {
+	for _, facet := range facets {
+		v.CheckError(validator.PermittedValue(facet, movieFacets...), "facets", "invalid facet value")
+	}
+	v.CheckError(validator.Unique(facets), "facets", "must not contain duplicate values")
+}
@@ -11,6 +11,8 @@ type Filters struct {
 	PageSize      int
 	Sort          string
 	SortWhiteList []string
+	Cursor        string
+	CursorMode    bool
 }
 
 func ValidateFilter(v *validator.Validator, f Filters) {
@@ -19,6 +21,12 @@ func ValidateFilter(v *validator.Validator, f Filters) {
 	v.CheckError(f.PageSize > 0, "page_size", "must be greater than zero")
 	v.CheckError(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 	v.CheckError(validator.PermittedValue(f.Sort, f.SortWhiteList...), "sort", "invalid sort value")
+
+	if f.CursorMode && f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		v.CheckError(err == nil, "cursor", "invalid cursor value")
+		v.CheckError(err != nil || c.Sort == f.Sort, "cursor", "must be used with the same sort value it was issued for")
+	}
 }
 
 func (f Filters) sortColumn() string {
@@ -39,6 +47,14 @@ func (f Filters) sortDirection() string {
 	return "ASC"
 }
 
+func flipDirection(direction string) string {
+	if direction == "DESC" {
+		return "ASC"
+	}
+
+	return "DESC"
+}
+
 func (f Filters) limit() int {
 	return f.PageSize
 }
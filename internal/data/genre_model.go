@@ -0,0 +1,301 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateGenre = errors.New("duplicate genre")
+
+type GenreModel struct {
+	DB *sql.DB
+}
+
+func (m GenreModel) GetAll() ([]Genre, error) {
+	query := `
+    SELECT genres.id, genres.name, genres.version,
+        COALESCE(array_agg(genre_aliases.alias ORDER BY genre_aliases.alias) FILTER (WHERE genre_aliases.alias IS NOT NULL), '{}')
+    FROM genres
+    LEFT JOIN genre_aliases ON genre_aliases.genre_id = genres.id AND genre_aliases.alias <> lower(genres.name)
+    GROUP BY genres.id
+    ORDER BY genres.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("data: query all genres: %s", err)
+	}
+	defer rows.Close()
+
+	genres := []Genre{}
+	for rows.Next() {
+		var genre Genre
+		err = rows.Scan(&genre.ID, &genre.Name, &genre.Version, pq.Array(&genre.Aliases))
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a genre: %s", err)
+		}
+
+		genres = append(genres, genre)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate all genres: %s", err)
+	}
+
+	return genres, nil
+}
+
+func (m GenreModel) Get(id int64) (*Genre, error) {
+	if id <= 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+    SELECT genres.id, genres.name, genres.version,
+        COALESCE(array_agg(genre_aliases.alias ORDER BY genre_aliases.alias) FILTER (WHERE genre_aliases.alias IS NOT NULL), '{}')
+    FROM genres
+    LEFT JOIN genre_aliases ON genre_aliases.genre_id = genres.id AND genre_aliases.alias <> lower(genres.name)
+    WHERE genres.id = $1
+    GROUP BY genres.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var genre Genre
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(&genre.ID, &genre.Name, &genre.Version, pq.Array(&genre.Aliases))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, fmt.Errorf("data: query a genre: %s", err)
+		}
+	}
+
+	return &genre, nil
+}
+
+func (m GenreModel) Catalog() (GenreCatalog, error) {
+	genres, err := m.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := GenreCatalog{}
+	for _, genre := range genres {
+		catalog.Add(genre)
+	}
+
+	return catalog, nil
+}
+
+func (m GenreModel) Insert(genre *Genre) error {
+	query := `
+    INSERT INTO genres (name)
+    VALUES ($1)
+    RETURNING id, version`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("data: begin genre transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, genre.Name).Scan(&genre.ID, &genre.Version)
+	if err != nil {
+		return genreError("insert a genre", err)
+	}
+
+	err = replaceGenreAliases(ctx, tx, genre)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("data: commit genre transaction: %s", err)
+	}
+
+	return nil
+}
+
+func (m GenreModel) Update(genre *Genre, previousName string, userID int64) error {
+	query := `
+    UPDATE genres
+    SET name = $1, version = version + 1
+    WHERE id = $2 AND version = $3
+    RETURNING version`
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("data: begin genre transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, genre.Name, genre.ID, genre.Version).Scan(&genre.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEditConflict
+		}
+		return genreError("update a genre", err)
+	}
+
+	err = replaceGenreAliases(ctx, tx, genre)
+	if err != nil {
+		return err
+	}
+
+	if previousName != genre.Name {
+		_, err = rewriteMovieGenre(ctx, tx, previousName, genre.Name, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("data: commit genre transaction: %s", err)
+	}
+
+	return nil
+}
+
+func (m GenreModel) Merge(source, target *Genre, userID int64) (int64, error) {
+	moveAliases := `
+    UPDATE genre_aliases
+    SET genre_id = $2
+    WHERE genre_id = $1`
+
+	deleteSource := `
+    DELETE FROM genres
+    WHERE id = $1 AND version = $2`
+
+	addAlias := `
+    INSERT INTO genre_aliases (alias, genre_id)
+    VALUES (lower($1), $2)
+    ON CONFLICT (alias) DO UPDATE SET genre_id = EXCLUDED.genre_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("data: begin genre transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, moveAliases, source.ID, target.ID)
+	if err != nil {
+		return 0, fmt.Errorf("data: move genre aliases: %s", err)
+	}
+
+	result, err := tx.ExecContext(ctx, deleteSource, source.ID, source.Version)
+	if err != nil {
+		return 0, fmt.Errorf("data: delete merged genre: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if rowsAffected == 0 {
+		return 0, ErrEditConflict
+	}
+
+	_, err = tx.ExecContext(ctx, addAlias, source.Name, target.ID)
+	if err != nil {
+		return 0, fmt.Errorf("data: alias merged genre: %s", err)
+	}
+
+	rewritten, err := rewriteMovieGenre(ctx, tx, source.Name, target.Name, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("data: commit genre transaction: %s", err)
+	}
+
+	return rewritten, nil
+}
+
+func replaceGenreAliases(ctx context.Context, tx *sql.Tx, genre *Genre) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM genre_aliases WHERE genre_id = $1`, genre.ID)
+	if err != nil {
+		return fmt.Errorf("data: delete genre aliases: %s", err)
+	}
+
+	// The lowered name is stored alongside the aliases so that a name can
+	// never collide with another genre's alias.
+	stmt := `
+    INSERT INTO genre_aliases (alias, genre_id)
+    SELECT unnest(array_append($1::text[], lower($2))), $3`
+
+	_, err = tx.ExecContext(ctx, stmt, pq.Array(genre.Aliases), genre.Name, genre.ID)
+	if err != nil {
+		return genreError("insert genre aliases", err)
+	}
+
+	return nil
+}
+
+func rewriteMovieGenre(ctx context.Context, tx *sql.Tx, from, to string, userID int64) (int64, error) {
+	query := `
+    WITH old AS (
+        SELECT id, title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+        FROM movies
+        WHERE genres @> ARRAY[$1::text]
+        FOR UPDATE
+    ), mv AS (
+        UPDATE movies
+        SET genres = ARRAY(
+                SELECT x.g
+                FROM unnest(array_replace(movies.genres, $1::text, $2::text)) WITH ORDINALITY AS x(g, n)
+                GROUP BY x.g
+                ORDER BY MIN(x.n)
+            ),
+            version = movies.version + 1
+        FROM old
+        WHERE movies.id = old.id
+        RETURNING movies.id, movies.version, movies.title, movies.year, movies.runtime, movies.genres,
+            movies.deleted_at, movies.poster_url, movies.backdrop_url
+    ), rev AS (
+        INSERT INTO movie_revisions (movie_id, version, user_id, before, after)
+        SELECT mv.id, mv.version, $3, ` + movieSnapshotJSON("old") + `, ` + movieSnapshotJSON("mv") + `
+        FROM mv
+        INNER JOIN old ON old.id = mv.id
+    )
+    SELECT COUNT(*) FROM mv`
+
+	var rewritten int64
+	err := tx.QueryRowContext(ctx, query, from, to, userID).Scan(&rewritten)
+	if err != nil {
+		return 0, fmt.Errorf("data: rewrite movie genre %q: %s", from, err)
+	}
+
+	return rewritten, nil
+}
+
+func genreError(action string, err error) error {
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicateGenre
+	}
+
+	return fmt.Errorf("data: %s: %s", action, err)
+}
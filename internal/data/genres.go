@@ -0,0 +1,56 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+type Genre struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Version int32    `json:"version"`
+}
+
+type GenreCatalog map[string]string
+
+func normaliseGenre(genre string) string {
+	return strings.ToLower(strings.TrimSpace(genre))
+}
+
+func (c GenreCatalog) Canonical(genre string) (string, bool) {
+	name, ok := c[normaliseGenre(genre)]
+	return name, ok
+}
+
+func (c GenreCatalog) Add(g Genre) {
+	c[normaliseGenre(g.Name)] = g.Name
+	for _, alias := range g.Aliases {
+		c[normaliseGenre(alias)] = g.Name
+	}
+}
+
+func ValidateGenre(v *validator.Validator, g *Genre, catalog GenreCatalog, current string) {
+	v.CheckError(validator.NotBlank(g.Name), "name", "must be provided")
+	v.CheckError(validator.LengthLessOrEqual(g.Name, 100), "name", "must not be greater than 100 characters")
+
+	v.CheckError(len(g.Aliases) <= 20, "aliases", "must not contain more than 20 aliases")
+	for i, alias := range g.Aliases {
+		g.Aliases[i] = normaliseGenre(alias)
+		v.CheckError(g.Aliases[i] != "", "aliases", "must not contain blank values")
+		v.CheckError(validator.LengthLessOrEqual(alias, 100), "aliases", "must not contain values greater than 100 characters")
+		v.CheckError(g.Aliases[i] != normaliseGenre(g.Name), "aliases", "must not contain the genre name")
+	}
+	v.CheckError(validator.Unique(g.Aliases), "aliases", "must not contain duplicate values")
+
+	if name, ok := catalog.Canonical(g.Name); ok && name != current {
+		v.AddFieldError("name", fmt.Sprintf("is already used by genre %q", name))
+	}
+	for _, alias := range g.Aliases {
+		if name, ok := catalog.Canonical(alias); ok && name != current {
+			v.AddFieldError("aliases", fmt.Sprintf("%q is already used by genre %q", alias, name))
+		}
+	}
+}
@@ -3,11 +3,13 @@ package data
 import "math"
 
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	FirstPage    int `json:"first_page,omitempty"`
-	LastPage     int `json:"last_page,omitempty"`
-	TotalRecords int `json:"total_record,omitempty"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_record,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
 }
 
 func makeMetadata(totalRecords, page, pageSize int) Metadata {
@@ -23,3 +25,11 @@ func makeMetadata(totalRecords, page, pageSize int) Metadata {
 		TotalRecords: totalRecords,
 	}
 }
+
+func makeCursorMetadata(pageSize int, nextCursor, prevCursor string) Metadata {
+	return Metadata{
+		PageSize:   pageSize,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+	}
+}
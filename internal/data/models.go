@@ -5,17 +5,35 @@ import (
 )
 
 type Models struct {
-	Movie      MovieModel
-	User       UserModel
-	Token      TokenModel
-	Permission PermissionModel
+	Movie         MovieModel
+	MovieRevision MovieRevisionModel
+	Review        ReviewModel
+	Watchlist     WatchlistModel
+	Watched       WatchedModel
+	Person        PersonModel
+	Credit        CreditModel
+	Genre         GenreModel
+	Collection    CollectionModel
+	Translation   TranslationModel
+	User          UserModel
+	Token         TokenModel
+	Permission    PermissionModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Movie:      MovieModel{DB: db},
-		User:       UserModel{DB: db},
-		Token:      TokenModel{DB: db},
-		Permission: PermissionModel{DB: db},
+		Movie:         MovieModel{DB: db},
+		MovieRevision: MovieRevisionModel{DB: db},
+		Review:        ReviewModel{DB: db},
+		Watchlist:     WatchlistModel{DB: db},
+		Watched:       WatchedModel{DB: db},
+		Person:        PersonModel{DB: db},
+		Credit:        CreditModel{DB: db},
+		Genre:         GenreModel{DB: db},
+		Collection:    CollectionModel{DB: db},
+		Translation:   TranslationModel{DB: db},
+		User:          UserModel{DB: db},
+		Token:         TokenModel{DB: db},
+		Permission:    PermissionModel{DB: db},
 	}
 }
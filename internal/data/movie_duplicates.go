@@ -0,0 +1,428 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+const (
+	DefaultDuplicateThreshold = 0.8
+	maxDuplicatePairs         = 10_000
+)
+
+var ErrTooManyDuplicatePairs = errors.New("too many duplicate pairs")
+
+type DuplicateCluster struct {
+	Similarity float64 `json:"similarity"`
+	Movies     []Movie `json:"movies"`
+}
+
+type duplicatePair struct {
+	a, b  int64
+	score float64
+}
+
+type MergeReport struct {
+	Reviews      int64 `json:"reviews"`
+	Credits      int64 `json:"credits"`
+	Watchlist    int64 `json:"watchlist"`
+	Watched      int64 `json:"watched"`
+	Collections  int64 `json:"collections"`
+	Translations int64 `json:"translations"`
+}
+
+func ValidateDuplicateThreshold(v *validator.Validator, threshold float64) {
+	v.CheckError(threshold >= 0.3, "threshold", "must be equal or greater than 0.3")
+	v.CheckError(threshold <= 1, "threshold", "must not be greater than 1")
+}
+
+func (m MovieModel) FindDuplicates(title string, year int32, threshold float64) ([]Movie, error) {
+	query := `
+    SELECT id, title, year, similarity(normalise_title(title), normalise_title($1)) AS score
+    FROM movies
+    WHERE deleted_at IS NULL
+    AND year = $2
+    AND normalise_title(title) % normalise_title($1)
+    AND similarity(normalise_title(title), normalise_title($1)) >= $3
+    ORDER BY score DESC, id ASC
+    LIMIT 5`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, title, year, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("data: query duplicate movies: %s", err)
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		var mv Movie
+		err = rows.Scan(&mv.ID, &mv.Title, &mv.Year, &mv.Score)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a duplicate movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate duplicate movies: %s", err)
+	}
+
+	return movies, nil
+}
+
+func (m MovieModel) GetDuplicateClusters(threshold float64, filter Filters) ([]DuplicateCluster, Metadata, error) {
+	query := `
+    SELECT a.id, b.id, similarity(normalise_title(a.title), normalise_title(b.title)) AS score
+    FROM movies AS a
+    INNER JOIN movies AS b
+        ON b.year = a.year
+        AND b.id > a.id
+        AND normalise_title(b.title) % normalise_title(a.title)
+    WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL
+    AND similarity(normalise_title(a.title), normalise_title(b.title)) >= $1
+    LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchQueryTimeout)
+	defer cancel()
+
+	// One extra pair is fetched to tell a full result from a truncated one,
+	// since clusters built from a truncated set would be wrong.
+	rows, err := m.DB.QueryContext(ctx, query, threshold, maxDuplicatePairs+1)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query duplicate pairs: %s", err)
+	}
+	defer rows.Close()
+
+	parent := map[int64]int64{}
+	var find func(id int64) int64
+	find = func(id int64) int64 {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+
+	similarity := map[int64]float64{}
+	pairs := []duplicatePair{}
+	for rows.Next() {
+		var a, b int64
+		var score float64
+		err = rows.Scan(&a, &b, &score)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a duplicate pair: %s", err)
+		}
+
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+		pairs = append(pairs, duplicatePair{a, b, score})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate duplicate pairs: %s", err)
+	}
+
+	if len(pairs) > maxDuplicatePairs {
+		return nil, Metadata{}, ErrTooManyDuplicatePairs
+	}
+
+	members := map[int64][]int64{}
+	for id := range parent {
+		root := find(id)
+		members[root] = append(members[root], id)
+	}
+	for _, pair := range pairs {
+		root := find(pair.a)
+		if pair.score > similarity[root] {
+			similarity[root] = pair.score
+		}
+	}
+
+	roots := make([]int64, 0, len(members))
+	for root, ids := range members {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		if len(members[roots[i]]) != len(members[roots[j]]) {
+			return len(members[roots[i]]) > len(members[roots[j]])
+		}
+		return members[roots[i]][0] < members[roots[j]][0]
+	})
+
+	start := filter.offset()
+	if start > len(roots) {
+		start = len(roots)
+	}
+	end := start + filter.limit()
+	if end > len(roots) {
+		end = len(roots)
+	}
+	page := roots[start:end]
+
+	ids := []int64{}
+	for _, root := range page {
+		ids = append(ids, members[root]...)
+	}
+
+	movies, err := m.GetByIDs(ids, nil)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	byID := make(map[int64]Movie, len(movies))
+	for _, mv := range movies {
+		byID[mv.ID] = mv
+	}
+
+	clusters := make([]DuplicateCluster, 0, len(page))
+	for _, root := range page {
+		cluster := DuplicateCluster{Similarity: similarity[root], Movies: []Movie{}}
+		for _, id := range members[root] {
+			if mv, ok := byID[id]; ok {
+				cluster.Movies = append(cluster.Movies, mv)
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, makeMetadata(len(roots), filter.Page, filter.PageSize), nil
+}
+
+func (m MovieModel) Merge(targetID, sourceID, userID int64) (MergeReport, error) {
+	var report MergeReport
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return report, fmt.Errorf("data: begin merge transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	lock := `
+    SELECT id
+    FROM movies
+    WHERE id IN ($1, $2) AND deleted_at IS NULL
+    ORDER BY id
+    FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, lock, targetID, sourceID)
+	if err != nil {
+		return report, fmt.Errorf("data: lock merged movies: %s", err)
+	}
+
+	found := 0
+	for rows.Next() {
+		found++
+	}
+	rows.Close()
+
+	err = rows.Err()
+	if err != nil {
+		return report, fmt.Errorf("data: lock merged movies: %s", err)
+	}
+
+	if found != 2 {
+		return report, ErrRecordNotFound
+	}
+
+	steps := []struct {
+		count *int64
+		move  string
+		drop  string
+	}{
+		{
+			count: &report.Reviews,
+			move: `
+            UPDATE reviews SET movie_id = $1
+            WHERE movie_id = $2
+            AND user_id NOT IN (SELECT user_id FROM reviews WHERE movie_id = $1)`,
+			drop: `DELETE FROM reviews WHERE movie_id = $2`,
+		},
+		{
+			count: &report.Credits,
+			move: `
+            UPDATE movie_credits AS c SET movie_id = $1
+            WHERE c.movie_id = $2
+            AND NOT EXISTS (
+                SELECT 1 FROM movie_credits AS t
+                WHERE t.movie_id = $1 AND t.person_id = c.person_id AND t.role = c.role AND t.character = c.character
+            )`,
+			drop: `DELETE FROM movie_credits WHERE movie_id = $2`,
+		},
+		{
+			count: &report.Watched,
+			move: `
+            UPDATE watched_movies SET movie_id = $1
+            WHERE movie_id = $2
+            AND user_id NOT IN (SELECT user_id FROM watched_movies WHERE movie_id = $1)`,
+			drop: `
+            WITH dropped AS (
+                DELETE FROM watched_movies WHERE movie_id = $2
+                RETURNING user_id, watched_on
+            )
+            UPDATE watched_movies AS w SET watched_on = GREATEST(w.watched_on, dropped.watched_on)
+            FROM dropped
+            WHERE w.movie_id = $1 AND w.user_id = dropped.user_id`,
+		},
+		{
+			count: &report.Collections,
+			move: `
+            UPDATE collection_movies SET movie_id = $1
+            WHERE movie_id = $2
+            AND collection_id NOT IN (SELECT collection_id FROM collection_movies WHERE movie_id = $1)`,
+			drop: `DELETE FROM collection_movies WHERE movie_id = $2`,
+		},
+		{
+			count: &report.Translations,
+			move: `
+            UPDATE movie_translations SET movie_id = $1
+            WHERE movie_id = $2
+            AND language NOT IN (SELECT language FROM movie_translations WHERE movie_id = $1)`,
+			drop: `DELETE FROM movie_translations WHERE movie_id = $2`,
+		},
+	}
+
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.move, targetID, sourceID)
+		if err != nil {
+			return report, fmt.Errorf("data: merge movies: %s", err)
+		}
+
+		*step.count, err = result.RowsAffected()
+		if err != nil {
+			return report, err
+		}
+
+		_, err = tx.ExecContext(ctx, step.drop, targetID, sourceID)
+		if err != nil {
+			return report, fmt.Errorf("data: merge movies: %s", err)
+		}
+	}
+
+	report.Watchlist, err = mergeWatchlistItems(ctx, tx, targetID, sourceID)
+	if err != nil {
+		return report, err
+	}
+
+	err = refreshMovieRating(ctx, tx, targetID)
+	if err != nil {
+		return report, err
+	}
+
+	source := `
+    WITH mv AS (
+        UPDATE movies
+        SET deleted_at = NOW(), average_rating = 0, rating_count = 0, version = version + 1
+        WHERE id = $1
+        RETURNING id, version, title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+    ), ` + movieRevisionCTE("$2", `'{"deleted": false}'`) + `
+    SELECT COUNT(*) FROM mv`
+
+	_, err = tx.ExecContext(ctx, source, sourceID, userID)
+	if err != nil {
+		return report, fmt.Errorf("data: delete merged movie: %s", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return report, fmt.Errorf("data: commit merge transaction: %s", err)
+	}
+
+	return report, nil
+}
+
+func mergeWatchlistItems(ctx context.Context, tx *sql.Tx, targetID, sourceID int64) (int64, error) {
+	lock := `
+    SELECT id FROM users
+    WHERE id IN (SELECT user_id FROM watchlist_items WHERE movie_id IN ($1, $2))
+    ORDER BY id
+    FOR NO KEY UPDATE`
+
+	_, err := tx.ExecContext(ctx, lock, targetID, sourceID)
+	if err != nil {
+		return 0, fmt.Errorf("data: lock watchlists: %s", err)
+	}
+
+	move := `
+    UPDATE watchlist_items SET movie_id = $1
+    WHERE movie_id = $2
+    AND user_id NOT IN (SELECT user_id FROM watchlist_items WHERE movie_id = $1)`
+
+	result, err := tx.ExecContext(ctx, move, targetID, sourceID)
+	if err != nil {
+		return 0, fmt.Errorf("data: merge watchlist items: %s", err)
+	}
+
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	drop := `
+    DELETE FROM watchlist_items WHERE movie_id = $1
+    RETURNING user_id`
+
+	rows, err := tx.QueryContext(ctx, drop, sourceID)
+	if err != nil {
+		return 0, fmt.Errorf("data: merge watchlist items: %s", err)
+	}
+
+	users := []int64{}
+	for rows.Next() {
+		var userID int64
+		err = rows.Scan(&userID)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("data: merge watchlist items: %s", err)
+		}
+		users = append(users, userID)
+	}
+	rows.Close()
+
+	err = rows.Err()
+	if err != nil {
+		return 0, fmt.Errorf("data: merge watchlist items: %s", err)
+	}
+
+	if len(users) == 0 {
+		return moved, nil
+	}
+
+	renumber := `
+    UPDATE watchlist_items AS w
+    SET position = ranked.position
+    FROM (
+        SELECT user_id, movie_id, row_number() OVER (PARTITION BY user_id ORDER BY position, added_at) AS position
+        FROM watchlist_items
+        WHERE user_id = ANY($1)
+    ) AS ranked
+    WHERE w.user_id = ranked.user_id AND w.movie_id = ranked.movie_id`
+
+	_, err = tx.ExecContext(ctx, renumber, pq.Array(users))
+	if err != nil {
+		return 0, fmt.Errorf("data: renumber watchlist items: %s", err)
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+var MovieFields = []string{"id", "title", "year", "runtime", "genres", "version", "average_rating", "rating_count", "poster_url", "poster_thumbnails", "backdrop_url", "backdrop_thumbnails"}
+
+func ValidateMovieFields(v *validator.Validator, fields []string) {
+	for _, field := range fields {
+		v.CheckError(validator.PermittedValue(field, MovieFields...), "fields", fmt.Sprintf("unknown field %q", field))
+	}
+	v.CheckError(validator.Unique(fields), "fields", "must not contain duplicate values")
+}
+
+func movieColumns(fields []string, extra ...string) []string {
+	if len(fields) == 0 {
+		return MovieFields
+	}
+
+	wanted := append([]string{"id"}, fields...)
+	wanted = append(wanted, extra...)
+
+	columns := []string{}
+	for _, field := range MovieFields {
+		if validator.PermittedValue(field, wanted...) {
+			columns = append(columns, field)
+		}
+	}
+
+	return columns
+}
+
+func (mv *Movie) scanDest(column string) any {
+	switch column {
+	case "id":
+		return &mv.ID
+	case "title":
+		return &mv.Title
+	case "year":
+		return &mv.Year
+	case "runtime":
+		return &mv.Runtime
+	case "genres":
+		return pq.Array(&mv.Genres)
+	case "version":
+		return &mv.Version
+	case "average_rating":
+		return &mv.AverageRating
+	case "rating_count":
+		return &mv.RatingCount
+	case "poster_url":
+		return &mv.PosterURL
+	case "poster_thumbnails":
+		return &mv.PosterThumbnails
+	case "backdrop_url":
+		return &mv.BackdropURL
+	case "backdrop_thumbnails":
+		return &mv.BackdropThumbnails
+	default:
+		panic(fmt.Sprintf("data: unknown movie column %q", column))
+	}
+}
+
+func (mv *Movie) scanDests(columns []string) []any {
+	dests := make([]any, 0, len(columns))
+	for _, column := range columns {
+		dests = append(dests, mv.scanDest(column))
+	}
+
+	return dests
+}
+
+func (mv Movie) Project(fields []string) map[string]any {
+	projection := map[string]any{"id": mv.ID}
+
+	for _, field := range fields {
+		switch field {
+		case "title":
+			projection["title"] = mv.Title
+			if mv.OriginalTitle != "" {
+				projection["original_title"] = mv.OriginalTitle
+			}
+		case "year":
+			projection["year"] = mv.Year
+		case "runtime":
+			projection["runtime"] = mv.Runtime
+		case "genres":
+			projection["genres"] = mv.Genres
+		case "version":
+			projection["version"] = mv.Version
+		case "average_rating":
+			projection["average_rating"] = mv.AverageRating
+		case "rating_count":
+			projection["rating_count"] = mv.RatingCount
+		case "poster_url":
+			projection["poster_url"] = mv.PosterURL
+		case "poster_thumbnails":
+			projection["poster_thumbnails"] = mv.PosterThumbnails
+		case "backdrop_url":
+			projection["backdrop_url"] = mv.BackdropURL
+		case "backdrop_thumbnails":
+			projection["backdrop_thumbnails"] = mv.BackdropThumbnails
+		}
+	}
+
+	if mv.Language != "" {
+		projection["language"] = mv.Language
+		if mv.Overview != "" {
+			projection["overview"] = mv.Overview
+		}
+	}
+
+	if mv.Score != 0 {
+		projection["score"] = mv.Score
+	}
+
+	if mv.Credits != nil {
+		projection["credits"] = mv.Credits
+	}
+
+	return projection
+}
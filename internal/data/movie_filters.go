@@ -0,0 +1,127 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+type MovieFilter struct {
+	Title         string
+	Genres        []string
+	GenresAny     []string
+	GenresNot     []string
+	YearMin       int
+	YearMax       int
+	RuntimeMin    int
+	RuntimeMax    int
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	InWatchlist   *bool
+	UserID        int64
+}
+
+func ValidateMovieFilter(v *validator.Validator, f MovieFilter) {
+	v.CheckError(f.YearMin == 0 || f.YearMin >= 1888, "year_min", "must be equal or greater than 1888")
+	v.CheckError(f.YearMax == 0 || f.YearMax >= 1888, "year_max", "must be equal or greater than 1888")
+	v.CheckError(f.YearMin == 0 || f.YearMax == 0 || f.YearMin <= f.YearMax, "year_min", "must not be greater than year_max")
+
+	v.CheckError(f.RuntimeMin >= 0, "runtime_min", "must not be negative")
+	v.CheckError(f.RuntimeMax >= 0, "runtime_max", "must not be negative")
+	v.CheckError(f.RuntimeMin == 0 || f.RuntimeMax == 0 || f.RuntimeMin <= f.RuntimeMax, "runtime_min", "must not be greater than runtime_max")
+
+	v.CheckError(
+		f.CreatedAfter.IsZero() || f.CreatedBefore.IsZero() || f.CreatedAfter.Before(f.CreatedBefore),
+		"created_after",
+		"must be before created_before",
+	)
+
+	v.CheckError(len(f.GenresAny) <= 20, "genres_any", "must not contain more than 20 genres")
+	v.CheckError(len(f.GenresNot) <= 20, "genres_not", "must not contain more than 20 genres")
+	for _, genre := range f.GenresNot {
+		v.CheckError(!validator.PermittedValue(genre, f.Genres...), "genres_not", "must not exclude a genre required by genres")
+	}
+}
+
+type queryArgs []any
+
+func (a *queryArgs) bind(val any) string {
+	*a = append(*a, val)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+func (f MovieFilter) condition(args *queryArgs) string {
+	conds := []string{"deleted_at IS NULL"}
+
+	if f.Title != "" {
+		conds = append(conds, fmt.Sprintf(
+			"(to_tsvector('simple', title) @@ plainto_tsquery('simple', %[1]s) OR %[1]s <%% title OR EXISTS ("+
+				"SELECT 1 FROM movie_translations t WHERE t.movie_id = movies.id "+
+				"AND (to_tsvector('simple', t.title) @@ plainto_tsquery('simple', %[1]s) OR %[1]s <%% t.title)))",
+			args.bind(f.Title),
+		))
+	}
+
+	if len(f.Genres) > 0 {
+		conds = append(conds, "genres @> "+args.bind(pq.Array(f.Genres)))
+	}
+
+	if len(f.GenresAny) > 0 {
+		conds = append(conds, "genres && "+args.bind(pq.Array(f.GenresAny)))
+	}
+
+	if len(f.GenresNot) > 0 {
+		conds = append(conds, "NOT (genres && "+args.bind(pq.Array(f.GenresNot))+")")
+	}
+
+	if f.YearMin != 0 {
+		conds = append(conds, "year >= "+args.bind(f.YearMin))
+	}
+
+	if f.YearMax != 0 {
+		conds = append(conds, "year <= "+args.bind(f.YearMax))
+	}
+
+	if f.RuntimeMin != 0 {
+		conds = append(conds, "runtime >= "+args.bind(f.RuntimeMin))
+	}
+
+	if f.RuntimeMax != 0 {
+		conds = append(conds, "runtime <= "+args.bind(f.RuntimeMax))
+	}
+
+	if !f.CreatedAfter.IsZero() {
+		conds = append(conds, "created_at > "+args.bind(f.CreatedAfter))
+	}
+
+	if !f.CreatedBefore.IsZero() {
+		conds = append(conds, "created_at < "+args.bind(f.CreatedBefore))
+	}
+
+	if f.InWatchlist != nil {
+		exists := "EXISTS (SELECT 1 FROM watchlist_items WHERE watchlist_items.movie_id = movies.id AND watchlist_items.user_id = " + args.bind(f.UserID) + ")"
+		if !*f.InWatchlist {
+			exists = "NOT " + exists
+		}
+		conds = append(conds, exists)
+	}
+
+	return strings.Join(conds, "\n    AND ")
+}
+
+func (f MovieFilter) score(args *queryArgs) string {
+	if f.Title == "" {
+		return "0"
+	}
+
+	return fmt.Sprintf(
+		"GREATEST(ts_rank(to_tsvector('simple', title), plainto_tsquery('simple', %[1]s)) + word_similarity(%[1]s, title), "+
+			"COALESCE((SELECT MAX(ts_rank(to_tsvector('simple', t.title), plainto_tsquery('simple', %[1]s)) + word_similarity(%[1]s, t.title)) "+
+			"FROM movie_translations t WHERE t.movie_id = movies.id), 0))",
+		args.bind(f.Title),
+	)
+}
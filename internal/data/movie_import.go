@@ -0,0 +1,103 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type MovieImport struct {
+	ctx    context.Context
+	tx     *sql.Tx
+	userID int64
+}
+
+func (m MovieModel) BeginImport(ctx context.Context, userID int64) (*MovieImport, error) {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("data: begin movie import: %s", err)
+	}
+
+	stmt := `
+    CREATE TEMPORARY TABLE movie_import (
+        position bigserial,
+        title text,
+        year integer,
+        runtime integer,
+        genres text[]
+    ) ON COMMIT DROP`
+
+	_, err = tx.ExecContext(ctx, stmt)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("data: create movie import table: %s", err)
+	}
+
+	return &MovieImport{ctx: ctx, tx: tx, userID: userID}, nil
+}
+
+func (mi *MovieImport) Add(movies []*Movie) error {
+	stmt, err := mi.tx.PrepareContext(mi.ctx, pq.CopyIn("movie_import", "title", "year", "runtime", "genres"))
+	if err != nil {
+		return fmt.Errorf("data: prepare movie import batch: %s", err)
+	}
+
+	for _, movie := range movies {
+		_, err = stmt.ExecContext(mi.ctx, movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres))
+		if err != nil {
+			stmt.Close()
+			return fmt.Errorf("data: copy a movie: %s", err)
+		}
+	}
+
+	_, err = stmt.ExecContext(mi.ctx)
+	if err != nil {
+		stmt.Close()
+		return fmt.Errorf("data: flush movie import batch: %s", err)
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return fmt.Errorf("data: close movie import batch: %s", err)
+	}
+
+	return nil
+}
+
+func (mi *MovieImport) Commit() error {
+	stmt := `
+    WITH mv AS (
+        INSERT INTO movies (title, year, runtime, genres)
+        SELECT title, year, runtime, genres
+        FROM movie_import
+        ORDER BY position
+        RETURNING id, version, title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+    )
+    INSERT INTO movie_revisions (movie_id, version, user_id, after)
+    SELECT mv.id, mv.version, $1, ` + movieSnapshotJSON("mv") + `
+    FROM mv`
+
+	_, err := mi.tx.ExecContext(mi.ctx, stmt, mi.userID)
+	if err != nil {
+		return fmt.Errorf("data: insert imported movies: %s", err)
+	}
+
+	err = mi.tx.Commit()
+	if err != nil {
+		return fmt.Errorf("data: commit movie import: %s", err)
+	}
+
+	return nil
+}
+
+func (mi *MovieImport) Rollback() error {
+	err := mi.tx.Rollback()
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("data: roll back movie import: %s", err)
+	}
+
+	return nil
+}
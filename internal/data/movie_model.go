@@ -5,22 +5,33 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type MovieModel struct {
 	DB *sql.DB
 }
 
-func (m MovieModel) Insert(movie *Movie) error {
+func (m MovieModel) Insert(movie *Movie, userID int64) error {
 	query := `
-    INSERT INTO movies (title, year, runtime, genres)
-    VALUES ($1, $2, $3, $4)
-    RETURNING id, created_at, version
+    WITH mv AS (
+        INSERT INTO movies (title, year, runtime, genres)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at, version, title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+    ), rev AS (
+        INSERT INTO movie_revisions (movie_id, version, user_id, after)
+        SELECT mv.id, mv.version, $5, ` + movieSnapshotJSON("mv") + `
+        FROM mv
+    )
+    SELECT id, created_at, version FROM mv
     `
 
-	args := []any{movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres)}
+	args := []any{movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres), userID}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
 	defer cancel()
 	err := m.DB.QueryRowContext(ctx, query, args...).
@@ -33,26 +44,25 @@ func (m MovieModel) Insert(movie *Movie) error {
 }
 
 func (m MovieModel) Get(id int64) (*Movie, error) {
+	return m.GetFields(id, nil)
+}
+
+func (m MovieModel) GetFields(id int64, fields []string) (*Movie, error) {
 	if id <= 0 {
 		return nil, ErrRecordNotFound
 	}
 
-	query := `
-    SELECT id, title, year, runtime, genres, created_at, version
+	columns := movieColumns(fields)
+	query := fmt.Sprintf(`
+    SELECT %s, created_at
     FROM movies
-    WHERE id = $1
-    `
+    WHERE id = $1 AND deleted_at IS NULL
+    `, strings.Join(columns, ", "))
+
 	var movie Movie
 	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
 	defer cancel()
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&movie.ID,
-		&movie.Title,
-		&movie.Year,
-		&movie.Runtime,
-		pq.Array(&movie.Genres),
-		&movie.CreatedAt,
-		&movie.Version)
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(append(movie.scanDests(columns), &movie.CreatedAt)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrRecordNotFound
@@ -64,12 +74,23 @@ func (m MovieModel) Get(id int64) (*Movie, error) {
 	return &movie, nil
 }
 
-func (m MovieModel) Update(movie *Movie) error {
+func (m MovieModel) Update(movie *Movie, userID int64) error {
 	query := `
-    UPDATE movies
-    SET title = $1, year = $2, runtime = $3, genres = $4, version = version + 1
-    WHERE id = $5 and version = $6
-    returning version
+    WITH old AS (
+        SELECT title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+        FROM movies
+        WHERE id = $5 AND version = $6 AND deleted_at IS NULL
+    ), mv AS (
+        UPDATE movies
+        SET title = $1, year = $2, runtime = $3, genres = $4, version = version + 1
+        WHERE id = $5 AND version = $6 AND deleted_at IS NULL
+        RETURNING id, version, title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+    ), rev AS (
+        INSERT INTO movie_revisions (movie_id, version, user_id, before, after)
+        SELECT mv.id, mv.version, $7, ` + movieSnapshotJSON("old") + `, ` + movieSnapshotJSON("mv") + `
+        FROM mv, old
+    )
+    SELECT version FROM mv
     `
 	args := []any{
 		movie.Title,
@@ -78,6 +99,7 @@ func (m MovieModel) Update(movie *Movie) error {
 		pq.Array(movie.Genres),
 		movie.ID,
 		movie.Version,
+		userID,
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
 	defer cancel()
@@ -93,59 +115,260 @@ func (m MovieModel) Update(movie *Movie) error {
 	return nil
 }
 
-func (m MovieModel) Delete(id int64) error {
-	stmt := `
-    DELETE FROM movies
-    WHERE id = $1
-    `
+func (m MovieModel) Delete(id, userID int64) error {
+	query := `
+    WITH mv AS (
+        UPDATE movies
+        SET deleted_at = NOW(), version = version + 1
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, version, title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+    ), ` + movieRevisionCTE("$2", `'{"deleted": false}'`) + `
+    SELECT id FROM mv`
 
 	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
 	defer cancel()
-	result, err := m.DB.ExecContext(ctx, stmt, id)
+	err := m.DB.QueryRowContext(ctx, query, id, userID).Scan(&id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+
 		return fmt.Errorf("data: delete a movie: %s", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return nil
+}
+
+func (m MovieModel) GetAll(mf MovieFilter, fields []string, filter Filters) ([]Movie, Metadata, error) {
+	if filter.CursorMode {
+		return m.getAllByCursor(mf, fields, filter)
+	}
+
+	args := queryArgs{}
+	column, direction := movieSortClause(filter)
+	columns := movieColumns(fields)
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), %s, %s AS score
+    FROM movies
+    WHERE %s
+    ORDER BY %s %s, id ASC
+    LIMIT %s OFFSET %s`,
+		strings.Join(columns, ", "),
+		mf.score(&args),
+		mf.condition(&args),
+		column,
+		direction,
+		args.bind(filter.limit()),
+		args.bind(filter.offset()),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	row, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("query all movie: %s", err)
+	}
+	defer row.Close()
+
+	totalRecords := 0
+	movies := []Movie{}
+	for row.Next() {
+		var mv Movie
+		dests := append([]any{&totalRecords}, mv.scanDests(columns)...)
+		err = row.Scan(append(dests, &mv.Score)...)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("scan a movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+
+	}
+
+	err = row.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("iterate all movie: %s", err)
+	}
+
+	return movies, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
+
+func (m MovieModel) getAllByCursor(mf MovieFilter, fields []string, filter Filters) ([]Movie, Metadata, error) {
+	var c cursor
+	if filter.Cursor != "" {
+		var err error
+		c, err = decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+	}
+
+	column, direction := movieSortClause(filter)
+	idDirection := "ASC"
+	if c.Backward {
+		direction, idDirection = flipDirection(direction), flipDirection(idDirection)
+	}
+
+	args := queryArgs{}
+	score, cond := mf.score(&args), mf.condition(&args)
+	keyset := "TRUE"
+	if filter.Cursor != "" {
+		keyset = c.keysetCondition(column, filter.sortDirection(), &args)
+	}
+
+	columns := movieColumns(fields, column)
+	query := fmt.Sprintf(`SELECT %s, %s AS score
+    FROM movies
+    WHERE %s
+    AND %s
+    ORDER BY %s %s, id %s
+    LIMIT %s`,
+		strings.Join(columns, ", "),
+		score,
+		cond,
+		keyset,
+		column,
+		direction,
+		idDirection,
+		args.bind(filter.limit()+1),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("query movies by cursor: %s", err)
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		var mv Movie
+		err = rows.Scan(append(mv.scanDests(columns), &mv.Score)...)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("scan a movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+	}
+
+	err = rows.Err()
 	if err != nil {
-		return err
+		return nil, Metadata{}, fmt.Errorf("iterate movies by cursor: %s", err)
+	}
+
+	hasMore := len(movies) > filter.limit()
+	if hasMore {
+		movies = movies[:filter.limit()]
 	}
 
-	if rowsAffected == 0 {
-		return ErrRecordNotFound
+	if c.Backward {
+		for i, j := 0, len(movies)-1; i < j; i, j = i+1, j-1 {
+			movies[i], movies[j] = movies[j], movies[i]
+		}
+	}
+
+	if len(movies) == 0 {
+		return movies, makeCursorMetadata(filter.PageSize, "", ""), nil
+	}
+
+	var next, prev string
+	first, last := movies[0], movies[len(movies)-1]
+	switch {
+	case c.Backward:
+		next = movieCursor(last, filter, false)
+		if hasMore {
+			prev = movieCursor(first, filter, true)
+		}
+	default:
+		if hasMore {
+			next = movieCursor(last, filter, false)
+		}
+		if filter.Cursor != "" {
+			prev = movieCursor(first, filter, true)
+		}
+	}
+
+	return movies, makeCursorMetadata(filter.PageSize, next, prev), nil
+}
+
+func (m MovieModel) DeleteVersion(id int64, version int32, userID int64) error {
+	query := `
+    WITH mv AS (
+        UPDATE movies
+        SET deleted_at = NOW(), version = version + 1
+        WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+        RETURNING id, version, title, year, runtime, genres, deleted_at, poster_url, backdrop_url
+    ), ` + movieRevisionCTE("$3", `'{"deleted": false}'`) + `
+    SELECT id FROM mv`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	err := m.DB.QueryRowContext(ctx, query, id, version, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEditConflict
+		}
+
+		return fmt.Errorf("data: delete a movie: %s", err)
 	}
 
 	return nil
 }
 
-func (m MovieModel) GetAll(title string, genres []string, filter Filters) ([]Movie, Metadata, error) {
-	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), id, title, year, runtime, genres, version
+func (m MovieModel) Restore(id, userID int64) (*Movie, error) {
+	query := `
+    WITH mv AS (
+        UPDATE movies
+        SET deleted_at = NULL, version = version + 1
+        WHERE id = $1 AND deleted_at IS NOT NULL
+        RETURNING id, title, year, runtime, genres, average_rating, rating_count, created_at, version, deleted_at, poster_url, backdrop_url
+    ), ` + movieRevisionCTE("$2", `'{"deleted": true}'`) + `
+    SELECT id, title, year, runtime, genres, average_rating, rating_count, created_at, version FROM mv`
+
+	var movie Movie
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	err := m.DB.QueryRowContext(ctx, query, id, userID).Scan(
+		&movie.ID,
+		&movie.Title,
+		&movie.Year,
+		&movie.Runtime,
+		pq.Array(&movie.Genres),
+		&movie.AverageRating,
+		&movie.RatingCount,
+		&movie.CreatedAt,
+		&movie.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+
+		return nil, fmt.Errorf("data: restore a movie: %s", err)
+	}
+
+	return &movie, nil
+}
+
+func (m MovieModel) GetAllDeleted(filter Filters) ([]Movie, Metadata, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), id, title, year, runtime, genres, version, deleted_at
     FROM movies
-    WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-    AND (genres @> $2 OR $2 = '{}')
+    WHERE deleted_at IS NOT NULL
     ORDER BY %s %s, id ASC
-    LIMIT $3 OFFSET $4`, filter.sortColumn(), filter.sortDirection())
+    LIMIT $1 OFFSET $2`, filter.sortColumn(), filter.sortDirection())
 
 	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
 	defer cancel()
-	row, err := m.DB.QueryContext(
-		ctx,
-		query,
-		title,
-		pq.Array(genres),
-		filter.limit(),
-		filter.offset(),
-	)
+	rows, err := m.DB.QueryContext(ctx, query, filter.limit(), filter.offset())
 	if err != nil {
-		return nil, Metadata{}, fmt.Errorf("query all movie: %s", err)
+		return nil, Metadata{}, fmt.Errorf("data: query deleted movies: %s", err)
 	}
-	defer row.Close()
+	defer rows.Close()
 
 	totalRecords := 0
 	movies := []Movie{}
-	for row.Next() {
+	for rows.Next() {
 		var mv Movie
-		err = row.Scan(
+		err = rows.Scan(
 			&totalRecords,
 			&mv.ID,
 			&mv.Title,
@@ -153,19 +376,230 @@ func (m MovieModel) GetAll(title string, genres []string, filter Filters) ([]Mov
 			&mv.Runtime,
 			pq.Array(&mv.Genres),
 			&mv.Version,
+			&mv.DeletedAt,
 		)
 		if err != nil {
-			return nil, Metadata{}, fmt.Errorf("scan a movie: %s", err)
+			return nil, Metadata{}, fmt.Errorf("data: scan a deleted movie: %s", err)
 		}
 
 		movies = append(movies, mv)
-
 	}
 
-	err = row.Err()
+	err = rows.Err()
 	if err != nil {
-		return nil, Metadata{}, fmt.Errorf("iterate all movie: %s", err)
+		return nil, Metadata{}, fmt.Errorf("data: iterate deleted movies: %s", err)
 	}
 
 	return movies, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
 }
+
+func (m MovieModel) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, []string, error) {
+	query := `
+    DELETE FROM movies
+    WHERE deleted_at IS NOT NULL AND deleted_at < $1
+    RETURNING poster_keys || backdrop_keys`
+
+	ctx, cancel := context.WithTimeout(ctx, batchQueryTimeout)
+	defer cancel()
+	rows, err := m.DB.QueryContext(ctx, query, time.Now().Add(-retention))
+	if err != nil {
+		return 0, nil, fmt.Errorf("data: purge deleted movies: %s", err)
+	}
+	defer rows.Close()
+
+	var purged int64
+	imageKeys := []string{}
+	for rows.Next() {
+		var keys []string
+		err = rows.Scan(pq.Array(&keys))
+		if err != nil {
+			return 0, nil, fmt.Errorf("data: scan purged movie: %s", err)
+		}
+
+		purged++
+		imageKeys = append(imageKeys, keys...)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return 0, nil, fmt.Errorf("data: purge deleted movies: %s", err)
+	}
+
+	return purged, imageKeys, nil
+}
+
+func (m MovieModel) GetByIDs(ids []int64, fields []string) ([]Movie, error) {
+	columns := movieColumns(fields)
+	query := fmt.Sprintf(`
+    SELECT %s
+    FROM movies
+    WHERE id = ANY($1) AND deleted_at IS NULL`, strings.Join(columns, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("data: query movies by ids: %s", err)
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		var mv Movie
+		err = rows.Scan(mv.scanDests(columns)...)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate movies by ids: %s", err)
+	}
+
+	return movies, nil
+}
+
+func (m MovieModel) Suggest(prefix string, limit int) ([]MovieSuggestion, error) {
+	query := `
+    SELECT id, title, year
+    FROM movies
+    WHERE lower(title) COLLATE "C" LIKE $1 AND deleted_at IS NULL
+    ORDER BY lower(title) COLLATE "C", id
+    LIMIT $2`
+
+	pattern := likeEscaper.Replace(strings.ToLower(strings.TrimSpace(prefix))) + "%"
+
+	ctx, cancel := context.WithTimeout(context.Background(), suggestQueryTimeout)
+	defer cancel()
+	rows, err := m.DB.QueryContext(ctx, query, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("data: query movie suggestions: %s", err)
+	}
+	defer rows.Close()
+
+	suggestions := []MovieSuggestion{}
+	for rows.Next() {
+		var s MovieSuggestion
+		err = rows.Scan(&s.ID, &s.Title, &s.Year)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a movie suggestion: %s", err)
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate movie suggestions: %s", err)
+	}
+
+	return suggestions, nil
+}
+
+func (m MovieModel) GetFacets(mf MovieFilter, facets []string) (Facets, error) {
+	queries := map[string]string{
+		"genres": `SELECT g, COUNT(*)
+    FROM movies, unnest(genres) AS g
+    WHERE %s
+    GROUP BY g
+    ORDER BY COUNT(*) DESC, g ASC`,
+		"year": `SELECT ((year / 10) * 10)::text || 's', COUNT(*)
+    FROM movies
+    WHERE %s
+    GROUP BY year / 10
+    ORDER BY year / 10 ASC`,
+	}
+
+	result := Facets{}
+	for _, facet := range facets {
+		args := queryArgs{}
+		query := fmt.Sprintf(queries[facet], mf.condition(&args))
+
+		counts, err := m.countFacet(query, args)
+		if err != nil {
+			return nil, fmt.Errorf("data: count %s facet: %s", facet, err)
+		}
+
+		result[facet] = counts
+	}
+
+	return result, nil
+}
+
+func (m MovieModel) countFacet(query string, args queryArgs) ([]FacetCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []FacetCount{}
+	for rows.Next() {
+		var fc FacetCount
+		err = rows.Scan(&fc.Value, &fc.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, fc)
+	}
+
+	return counts, rows.Err()
+}
+
+func movieSortClause(filter Filters) (string, string) {
+	switch filter.sortColumn() {
+	case "relevance":
+		return "score", "DESC"
+	case "rating":
+		return "average_rating", filter.sortDirection()
+	}
+
+	return filter.sortColumn(), filter.sortDirection()
+}
+
+func (m MovieModel) Stream(ctx context.Context, mf MovieFilter, filter Filters, fn func(*Movie) error) error {
+	args := queryArgs{}
+	column, direction := movieSortClause(filter)
+	query := fmt.Sprintf(`SELECT %s, %s AS score
+    FROM movies
+    WHERE %s
+    ORDER BY %s %s, id ASC`,
+		strings.Join(MovieFields, ", "),
+		mf.score(&args),
+		mf.condition(&args),
+		column,
+		direction,
+	)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("data: query movies stream: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mv Movie
+		err = rows.Scan(append(mv.scanDests(MovieFields), &mv.Score)...)
+		if err != nil {
+			return fmt.Errorf("data: scan a movie: %s", err)
+		}
+
+		err = fn(&mv)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("data: iterate movies stream: %s", err)
+	}
+
+	return nil
+}
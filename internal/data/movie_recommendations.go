@@ -0,0 +1,273 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const movieSimilarityJoins = `
+    CROSS JOIN LATERAL (
+        SELECT COUNT(*) AS shared
+        FROM unnest(m.genres) AS g
+        WHERE g = ANY(s.genres)
+    ) AS genre_overlap
+    CROSS JOIN LATERAL (
+        SELECT COUNT(DISTINCT c.person_id) AS shared
+        FROM movie_credits AS c
+        INNER JOIN movie_credits AS sc ON sc.person_id = c.person_id AND sc.movie_id = s.id
+        WHERE c.movie_id = m.id
+    ) AS credit_overlap
+    CROSS JOIN LATERAL (
+        SELECT COUNT(*) AS shared
+        FROM reviews AS r
+        INNER JOIN reviews AS sr ON sr.user_id = r.user_id AND sr.movie_id = s.id AND sr.score >= 7
+        WHERE r.movie_id = m.id AND r.score >= 7
+    ) AS co_rating`
+
+const movieSimilarityScore = `(
+        0.4 * genre_overlap.shared::float8 / GREATEST(cardinality(m.genres) + cardinality(s.genres) - genre_overlap.shared, 1)
+        + 0.25 * LEAST(credit_overlap.shared, 5)::float8 / 5
+        + 0.25 * LEAST(co_rating.shared, 10)::float8 / 10
+        + 0.1 / (1 + abs(m.year - s.year)::float8 / 5)
+    )`
+
+const movieSimilarityRelevant = `(genre_overlap.shared > 0 OR credit_overlap.shared > 0 OR co_rating.shared > 0)`
+
+const (
+	maxSimilarCandidates   = 100
+	maxRecommendationSeeds = 20
+)
+
+var movieCandidatePairs = fmt.Sprintf(`
+    pairs AS (
+        SELECT DISTINCT s.id AS seed_id, candidate.movie_id
+        FROM seeds AS s
+        CROSS JOIN LATERAL (
+            (
+                SELECT m.id AS movie_id
+                FROM movies AS m
+                WHERE m.genres && s.genres AND m.id <> s.id AND m.deleted_at IS NULL
+                ORDER BY m.average_rating DESC, m.id
+                LIMIT %[1]d
+            )
+            UNION
+            (
+                SELECT c.movie_id
+                FROM movie_credits AS sc
+                INNER JOIN movie_credits AS c ON c.person_id = sc.person_id
+                WHERE sc.movie_id = s.id AND c.movie_id <> s.id
+                LIMIT %[1]d
+            )
+            UNION
+            (
+                SELECT r.movie_id
+                FROM reviews AS sr
+                INNER JOIN reviews AS r ON r.user_id = sr.user_id AND r.score >= 7
+                WHERE sr.movie_id = s.id AND sr.score >= 7 AND r.movie_id <> s.id
+                LIMIT %[1]d
+            )
+        ) AS candidate
+    )`, maxSimilarCandidates)
+
+func (m MovieModel) GetSimilar(movieID, userID int64, fields []string, filter Filters) ([]Movie, Metadata, error) {
+	// Scoring every movie does not scale, so each seed first nominates a
+	// bounded set of candidates through the indexed genre, credit and
+	// co-rating relations and only those pairs are scored.
+	args := queryArgs{}
+	scored := fmt.Sprintf(`
+    seeds AS (
+        SELECT id, genres, year, 1::float8 AS weight
+        FROM movies
+        WHERE id = %[1]s AND deleted_at IS NULL
+    ),
+    %[3]s,
+    scored AS (
+        SELECT m.id AS movie_id, %[4]s AS score
+        FROM pairs AS p
+        INNER JOIN seeds AS s ON s.id = p.seed_id
+        INNER JOIN movies AS m ON m.id = p.movie_id
+        %[5]s
+        WHERE m.deleted_at IS NULL
+        AND NOT EXISTS (SELECT 1 FROM watched_movies AS w WHERE w.movie_id = m.id AND w.user_id = %[2]s)
+        AND %[6]s
+    )`,
+		args.bind(movieID),
+		args.bind(userID),
+		movieCandidatePairs,
+		movieSimilarityScore,
+		movieSimilarityJoins,
+		movieSimilarityRelevant,
+	)
+
+	movies, metadata, err := m.getScored(scored, args, fields, filter)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: get movies similar to id=%d: %s", movieID, err)
+	}
+
+	return movies, metadata, nil
+}
+
+func (m MovieModel) GetRecommendations(userID int64, fields []string, filter Filters) ([]Movie, Metadata, error) {
+	// Seeds are weighted by how much the user liked them, and only the
+	// strongest ones are used.
+	args := queryArgs{}
+	user := args.bind(userID)
+	seeds := fmt.Sprintf(`
+    seeds AS (
+        SELECT s.id, s.genres, s.year, history.weight
+        FROM (
+            SELECT movie_id, (score - 5)::float8 / 5 AS weight
+            FROM reviews
+            WHERE user_id = %[1]s AND score >= 6
+            UNION ALL
+            SELECT movie_id, 0.5
+            FROM watched_movies
+            WHERE user_id = %[1]s
+            AND movie_id NOT IN (SELECT movie_id FROM reviews WHERE user_id = %[1]s)
+        ) AS history
+        INNER JOIN movies AS s ON s.id = history.movie_id
+        WHERE s.deleted_at IS NULL
+        ORDER BY history.weight DESC, s.id DESC
+        LIMIT %[2]d
+    )`, user, maxRecommendationSeeds)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var hasSeeds bool
+	err := m.DB.QueryRowContext(ctx, "WITH "+seeds+" SELECT EXISTS (SELECT 1 FROM seeds)", args...).Scan(&hasSeeds)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: get recommendations for user with id=%d: %s", userID, err)
+	}
+
+	if !hasSeeds {
+		movies, metadata, err := m.getTopRatedUnseen(userID, fields, filter)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: get recommendations for user with id=%d: %s", userID, err)
+		}
+
+		return movies, metadata, nil
+	}
+
+	scored := fmt.Sprintf(`%[1]s,
+    %[3]s,
+    scored AS (
+        SELECT m.id AS movie_id, SUM(s.weight * %[4]s) / (SELECT SUM(weight) FROM seeds) AS score
+        FROM pairs AS p
+        INNER JOIN seeds AS s ON s.id = p.seed_id
+        INNER JOIN movies AS m ON m.id = p.movie_id
+        %[5]s
+        WHERE m.deleted_at IS NULL
+        AND NOT EXISTS (SELECT 1 FROM watched_movies AS w WHERE w.movie_id = m.id AND w.user_id = %[2]s)
+        AND NOT EXISTS (SELECT 1 FROM reviews AS r WHERE r.movie_id = m.id AND r.user_id = %[2]s)
+        AND %[6]s
+        GROUP BY m.id
+    )`,
+		seeds,
+		user,
+		movieCandidatePairs,
+		movieSimilarityScore,
+		movieSimilarityJoins,
+		movieSimilarityRelevant,
+	)
+
+	movies, metadata, err := m.getScored(scored, args, fields, filter)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: get recommendations for user with id=%d: %s", userID, err)
+	}
+
+	return movies, metadata, nil
+}
+
+func (m MovieModel) getTopRatedUnseen(userID int64, fields []string, filter Filters) ([]Movie, Metadata, error) {
+	// Users without any history get the best rated movies they have not seen,
+	// which the rating index can serve without scoring the catalogue.
+	condition := `deleted_at IS NULL
+    AND NOT EXISTS (SELECT 1 FROM watched_movies AS w WHERE w.movie_id = movies.id AND w.user_id = $1)
+    AND NOT EXISTS (SELECT 1 FROM reviews AS r WHERE r.movie_id = movies.id AND r.user_id = $1)`
+
+	columns := movieColumns(fields)
+	query := fmt.Sprintf(`SELECT %s
+    FROM movies
+    WHERE %s
+    ORDER BY average_rating DESC, rating_count DESC, id ASC
+    LIMIT $2 OFFSET $3`, strings.Join(columns, ", "), condition)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var totalRecords int
+	err := m.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM movies WHERE "+condition, userID).Scan(&totalRecords)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("count unseen movies: %s", err)
+	}
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, filter.limit(), filter.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("query unseen movies: %s", err)
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		var mv Movie
+		err = rows.Scan(mv.scanDests(columns)...)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("scan an unseen movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("iterate unseen movies: %s", err)
+	}
+
+	return movies, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
+
+func (m MovieModel) getScored(ctes string, args queryArgs, fields []string, filter Filters) ([]Movie, Metadata, error) {
+	columns := movieColumns(fields)
+	query := fmt.Sprintf(`WITH %s
+    SELECT COUNT(*) OVER(), %s, score
+    FROM movies
+    INNER JOIN scored ON scored.movie_id = movies.id
+    ORDER BY score DESC, average_rating DESC, rating_count DESC, id ASC
+    LIMIT %s OFFSET %s`,
+		ctes,
+		strings.Join(columns, ", "),
+		args.bind(filter.limit()),
+		args.bind(filter.offset()),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("query scored movies: %s", err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	movies := []Movie{}
+	for rows.Next() {
+		var mv Movie
+		dests := append([]any{&totalRecords}, mv.scanDests(columns)...)
+		err = rows.Scan(append(dests, &mv.Score)...)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("scan a scored movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("iterate scored movies: %s", err)
+	}
+
+	return movies, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
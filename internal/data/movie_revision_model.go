@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type MovieRevisionModel struct {
+	DB *sql.DB
+}
+
+func (m MovieRevisionModel) GetAllForMovie(movieID int64, filter Filters) ([]MovieRevision, Metadata, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), movie_id, version, user_id, created_at, before, after
+    FROM movie_revisions
+    WHERE movie_id = $1
+    ORDER BY %s %s
+    LIMIT $2 OFFSET $3`, filter.sortColumn(), filter.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	rows, err := m.DB.QueryContext(ctx, query, movieID, filter.limit(), filter.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query revisions of movie with id=%d: %s", movieID, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	revisions := []MovieRevision{}
+	for rows.Next() {
+		var rev MovieRevision
+		err = scanRevision(rows, &rev, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a revision of movie with id=%d: %s", movieID, err)
+		}
+
+		revisions = append(revisions, rev)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate revisions of movie with id=%d: %s", movieID, err)
+	}
+
+	return revisions, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
+
+func (m MovieRevisionModel) Get(movieID int64, version int32) (*MovieRevision, error) {
+	query := `
+    SELECT movie_id, version, user_id, created_at, before, after
+    FROM movie_revisions
+    WHERE movie_id = $1 AND version = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var rev MovieRevision
+	err := scanRevision(m.DB.QueryRowContext(ctx, query, movieID, version), &rev)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, fmt.Errorf("data: query revision %d of movie with id=%d: %s", version, movieID, err)
+		}
+	}
+
+	return &rev, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRevision(row rowScanner, rev *MovieRevision, leading ...any) error {
+	var before, after []byte
+	dests := append(leading, &rev.MovieID, &rev.Version, &rev.UserID, &rev.CreatedAt, &before, &after)
+	err := row.Scan(dests...)
+	if err != nil {
+		return err
+	}
+
+	if before != nil {
+		var rec snapshotRecord
+		err = json.Unmarshal(before, &rec)
+		if err != nil {
+			return err
+		}
+
+		snapshot := rec.snapshot()
+		rev.Before = &snapshot
+	}
+
+	var rec snapshotRecord
+	err = json.Unmarshal(after, &rec)
+	if err != nil {
+		return err
+	}
+	rev.After = rec.snapshot()
+	rev.Diff = diffSnapshots(rev.Before, rev.After)
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package data
+
+import (
+	"fmt"
+	"time"
+)
+
+func movieSnapshotJSON(rel string) string {
+	return fmt.Sprintf(`jsonb_build_object(
+            'title', %[1]s.title, 'year', %[1]s.year, 'runtime', %[1]s.runtime, 'genres', %[1]s.genres,
+            'deleted', %[1]s.deleted_at IS NOT NULL, 'poster_url', %[1]s.poster_url, 'backdrop_url', %[1]s.backdrop_url
+        )`, rel)
+}
+
+func movieRevisionCTE(userArg, changed string) string {
+	// The before snapshot is the after snapshot with the changed fields set
+	// back to their previous values.
+	return `rev AS (
+        INSERT INTO movie_revisions (movie_id, version, user_id, before, after)
+        SELECT mv.id, mv.version, ` + userArg + `, ` + movieSnapshotJSON("mv") + ` || ` + changed + `, ` + movieSnapshotJSON("mv") + `
+        FROM mv
+    )`
+}
+
+type MovieSnapshot struct {
+	Title       string   `json:"title"`
+	Year        int32    `json:"year"`
+	Runtime     RunTime  `json:"runtime"`
+	Genres      []string `json:"genres"`
+	Deleted     bool     `json:"deleted"`
+	PosterURL   string   `json:"poster_url,omitempty"`
+	BackdropURL string   `json:"backdrop_url,omitempty"`
+}
+
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type MovieRevision struct {
+	MovieID   int64                  `json:"movie_id"`
+	Version   int32                  `json:"version"`
+	UserID    *int64                 `json:"user_id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Before    *MovieSnapshot         `json:"before"`
+	After     MovieSnapshot          `json:"after"`
+	Diff      map[string]FieldChange `json:"diff"`
+}
+
+type snapshotRecord struct {
+	Title       string   `json:"title"`
+	Year        int32    `json:"year"`
+	Runtime     int32    `json:"runtime"`
+	Genres      []string `json:"genres"`
+	Deleted     bool     `json:"deleted"`
+	PosterURL   string   `json:"poster_url"`
+	BackdropURL string   `json:"backdrop_url"`
+}
+
+func (r snapshotRecord) snapshot() MovieSnapshot {
+	return MovieSnapshot{
+		Title:       r.Title,
+		Year:        r.Year,
+		Runtime:     RunTime(r.Runtime),
+		Genres:      r.Genres,
+		Deleted:     r.Deleted,
+		PosterURL:   r.PosterURL,
+		BackdropURL: r.BackdropURL,
+	}
+}
+
+func (s MovieSnapshot) ApplyTo(movie *Movie) {
+	movie.Title = s.Title
+	movie.Year = s.Year
+	movie.Runtime = s.Runtime
+	movie.Genres = s.Genres
+}
+
+func diffSnapshots(before *MovieSnapshot, after MovieSnapshot) map[string]FieldChange {
+	diff := map[string]FieldChange{}
+	if before == nil {
+		before = &MovieSnapshot{}
+	}
+
+	if before.Title != after.Title {
+		diff["title"] = FieldChange{From: before.Title, To: after.Title}
+	}
+
+	if before.Year != after.Year {
+		diff["year"] = FieldChange{From: before.Year, To: after.Year}
+	}
+
+	if before.Runtime != after.Runtime {
+		diff["runtime"] = FieldChange{From: before.Runtime, To: after.Runtime}
+	}
+
+	if !equalGenres(before.Genres, after.Genres) {
+		diff["genres"] = FieldChange{From: before.Genres, To: after.Genres}
+	}
+
+	if before.Deleted != after.Deleted {
+		diff["deleted"] = FieldChange{From: before.Deleted, To: after.Deleted}
+	}
+
+	if before.PosterURL != after.PosterURL {
+		diff["poster_url"] = FieldChange{From: before.PosterURL, To: after.PosterURL}
+	}
+
+	if before.BackdropURL != after.BackdropURL {
+		diff["backdrop_url"] = FieldChange{From: before.BackdropURL, To: after.BackdropURL}
+	}
+
+	return diff
+}
+
+func equalGenres(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
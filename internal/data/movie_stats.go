@@ -0,0 +1,170 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	statsWeeks        = 12
+	statsMostReviewed = 10
+)
+
+var runtimePercentiles = []float64{0.25, 0.5, 0.75, 0.9}
+
+type RuntimeStats struct {
+	Min         int32              `json:"min"`
+	Max         int32              `json:"max"`
+	Mean        float64            `json:"mean"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+type WeeklyCount struct {
+	Week  time.Time `json:"week"`
+	Count int       `json:"count"`
+}
+
+type MovieStats struct {
+	TotalMovies  int           `json:"total_movies"`
+	ByGenre      []FacetCount  `json:"by_genre"`
+	ByDecade     []FacetCount  `json:"by_decade"`
+	Runtime      RuntimeStats  `json:"runtime"`
+	AddedPerWeek []WeeklyCount `json:"added_per_week"`
+	MostReviewed []Movie       `json:"most_reviewed"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+}
+
+func (m MovieModel) GetStats() (*MovieStats, error) {
+	facets, err := m.GetFacets(MovieFilter{}, movieFacets)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &MovieStats{
+		ByGenre:     facets["genres"],
+		ByDecade:    facets["year"],
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchQueryTimeout)
+	defer cancel()
+
+	runtime := `
+    SELECT COUNT(*), COALESCE(MIN(runtime), 0), COALESCE(MAX(runtime), 0), COALESCE(AVG(runtime), 0),
+        COALESCE(percentile_cont($1::float8[]) WITHIN GROUP (ORDER BY runtime), '{}')
+    FROM movies
+    WHERE deleted_at IS NULL`
+
+	var percentiles []float64
+	err = m.DB.QueryRowContext(ctx, runtime, pq.Array(runtimePercentiles)).Scan(
+		&stats.TotalMovies,
+		&stats.Runtime.Min,
+		&stats.Runtime.Max,
+		&stats.Runtime.Mean,
+		pq.Array(&percentiles),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("data: query runtime stats: %s", err)
+	}
+
+	stats.Runtime.Percentiles = map[string]float64{}
+	for i, p := range percentiles {
+		stats.Runtime.Percentiles[fmt.Sprintf("p%.0f", runtimePercentiles[i]*100)] = p
+	}
+
+	stats.AddedPerWeek, err = m.countAddedPerWeek(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.MostReviewed, err = m.getMostReviewed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (m MovieModel) countAddedPerWeek(ctx context.Context) ([]WeeklyCount, error) {
+	query := `
+    SELECT weeks.week, COUNT(movies.id)
+    FROM generate_series(
+        date_trunc('week', NOW()) - ($1 - 1) * interval '1 week',
+        date_trunc('week', NOW()),
+        interval '1 week'
+    ) AS weeks(week)
+    LEFT JOIN movies
+        ON date_trunc('week', movies.created_at) = weeks.week
+        AND movies.deleted_at IS NULL
+    GROUP BY weeks.week
+    ORDER BY weeks.week`
+
+	rows, err := m.DB.QueryContext(ctx, query, statsWeeks)
+	if err != nil {
+		return nil, fmt.Errorf("data: query movies added per week: %s", err)
+	}
+	defer rows.Close()
+
+	counts := []WeeklyCount{}
+	for rows.Next() {
+		var count WeeklyCount
+		err = rows.Scan(&count.Week, &count.Count)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan movies added per week: %s", err)
+		}
+
+		counts = append(counts, count)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate movies added per week: %s", err)
+	}
+
+	return counts, nil
+}
+
+func (m MovieModel) getMostReviewed(ctx context.Context) ([]Movie, error) {
+	query := `
+    SELECT id, title, year, runtime, genres, version, average_rating, rating_count
+    FROM movies
+    WHERE deleted_at IS NULL AND rating_count > 0
+    ORDER BY rating_count DESC, average_rating DESC, id ASC
+    LIMIT $1`
+
+	rows, err := m.DB.QueryContext(ctx, query, statsMostReviewed)
+	if err != nil {
+		return nil, fmt.Errorf("data: query most reviewed movies: %s", err)
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		var mv Movie
+		err = rows.Scan(
+			&mv.ID,
+			&mv.Title,
+			&mv.Year,
+			&mv.Runtime,
+			pq.Array(&mv.Genres),
+			&mv.Version,
+			&mv.AverageRating,
+			&mv.RatingCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a most reviewed movie: %s", err)
+		}
+
+		movies = append(movies, mv)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate most reviewed movies: %s", err)
+	}
+
+	return movies, nil
+}
@@ -1,24 +1,47 @@
 package data
 
 import (
+	"fmt"
 	"time"
 
 	"huytran2000-hcmus/greenlight/internal/validator"
 )
 
-var defaultQueryTimeout = 3 * time.Second
+var (
+	defaultQueryTimeout = 3 * time.Second
+	suggestQueryTimeout = 500 * time.Millisecond
+	batchQueryTimeout   = 30 * time.Second
+)
 
 type Movie struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Year      int32     `json:"year,omitempty"`
-	Runtime   RunTime   `json:"runtime,omitempty"`
-	Genres    []string  `json:"genres,omitempty"`
-	Version   int32     `json:"version"`
-	CreatedAt time.Time `json:"-"`
+	ID                 int64           `json:"id"`
+	Title              string          `json:"title"`
+	OriginalTitle      string          `json:"original_title,omitempty"`
+	Overview           string          `json:"overview,omitempty"`
+	Language           string          `json:"language,omitempty"`
+	Year               int32           `json:"year,omitempty"`
+	Runtime            RunTime         `json:"runtime,omitempty"`
+	Genres             []string        `json:"genres,omitempty"`
+	Version            int32           `json:"version"`
+	AverageRating      float64         `json:"average_rating"`
+	RatingCount        int32           `json:"rating_count"`
+	PosterURL          string          `json:"poster_url,omitempty"`
+	PosterThumbnails   ImageThumbnails `json:"poster_thumbnails,omitempty"`
+	BackdropURL        string          `json:"backdrop_url,omitempty"`
+	BackdropThumbnails ImageThumbnails `json:"backdrop_thumbnails,omitempty"`
+	Credits            []MovieCredit   `json:"credits,omitempty"`
+	Score              float64         `json:"score,omitempty"`
+	CreatedAt          time.Time       `json:"-"`
+	DeletedAt          *time.Time      `json:"deleted_at,omitempty"`
+}
+
+type MovieSuggestion struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Year  int32  `json:"year"`
 }
 
-func ValidateMovie(v *validator.Validator, m *Movie) {
+func ValidateMovie(v *validator.Validator, m *Movie, genres GenreCatalog) {
 	v.CheckError(validator.NotBlank(m.Title), "title", "must be provided")
 	v.CheckError(
 		validator.LengthLessOrEqual(m.Title, 500),
@@ -36,5 +59,28 @@ func ValidateMovie(v *validator.Validator, m *Movie) {
 	v.CheckError(m.Genres != nil, "genres", "must be provided")
 	v.CheckError(len(m.Genres) >= 1, "genres", "must contain at least 1 genre")
 	v.CheckError(len(m.Genres) <= 5, "genres", "must not contain more than 5 genres")
+	if genres != nil {
+		for i, genre := range m.Genres {
+			name, ok := genres.Canonical(genre)
+			if !ok {
+				v.AddFieldError("genres", fmt.Sprintf("unknown genre %q", genre))
+				continue
+			}
+			m.Genres[i] = name
+		}
+	}
 	v.CheckError(validator.Unique(m.Genres), "genres", "must not contain duplicate values")
 }
+
+func ValidateSuggestQuery(v *validator.Validator, prefix string, limit int) {
+	v.CheckError(validator.NotBlank(prefix), "q", "must be provided")
+	v.CheckError(validator.LengthLessOrEqual(prefix, 100), "q", "must not be greater than 100 characters")
+	v.CheckError(limit > 0, "limit", "must be greater than zero")
+	v.CheckError(limit <= 20, "limit", "must be a maximum of 20")
+}
+
+func ValidateMovieIDs(v *validator.Validator, ids []int64, max int) {
+	v.CheckError(len(ids) > 0, "ids", "must contain at least 1 id")
+	v.CheckError(len(ids) <= max, "ids", fmt.Sprintf("must not contain more than %d ids", max))
+	v.CheckError(validator.Unique(ids), "ids", "must not contain duplicate values")
+}
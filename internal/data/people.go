@@ -0,0 +1,45 @@
+package data
+
+import (
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+var CreditRoles = []string{"director", "writer", "actor"}
+
+type Person struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	BirthDate *time.Time `json:"birth_date,omitempty"`
+	Biography string     `json:"biography,omitempty"`
+	CreatedAt time.Time  `json:"-"`
+	Version   int32      `json:"version"`
+}
+
+type MovieCredit struct {
+	ID           int64  `json:"id"`
+	MovieID      int64  `json:"movie_id"`
+	MovieTitle   string `json:"movie_title,omitempty"`
+	MovieYear    int32  `json:"movie_year,omitempty"`
+	PersonID     int64  `json:"person_id"`
+	PersonName   string `json:"person_name,omitempty"`
+	Role         string `json:"role"`
+	Character    string `json:"character,omitempty"`
+	BillingOrder int32  `json:"billing_order"`
+}
+
+func ValidatePerson(v *validator.Validator, p *Person) {
+	v.CheckError(validator.NotBlank(p.Name), "name", "must be provided")
+	v.CheckError(validator.LengthLessOrEqual(p.Name, 500), "name", "must not be greater than 500 characters")
+	v.CheckError(validator.LengthLessOrEqual(p.Biography, 10000), "biography", "must not be greater than 10000 characters")
+	v.CheckError(p.BirthDate == nil || !p.BirthDate.After(time.Now()), "birth_date", "must not be in the future")
+}
+
+func ValidateMovieCredit(v *validator.Validator, c *MovieCredit) {
+	v.CheckError(c.PersonID > 0, "person_id", "must be provided")
+	v.CheckError(validator.PermittedValue(c.Role, CreditRoles...), "role", "must be one of director, writer or actor")
+	v.CheckError(c.Role == "actor" || c.Character == "", "character", "must only be provided for actors")
+	v.CheckError(validator.LengthLessOrEqual(c.Character, 500), "character", "must not be greater than 500 characters")
+	v.CheckError(c.BillingOrder >= 0, "billing_order", "must not be negative")
+}
@@ -0,0 +1,324 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDuplicateCredit = errors.New("duplicate credit")
+	ErrInvalidPerson   = errors.New("invalid person")
+)
+
+type PersonModel struct {
+	DB *sql.DB
+}
+
+func (m PersonModel) Insert(person *Person) error {
+	query := `
+    INSERT INTO people (name, birth_date, biography)
+    VALUES ($1, $2, $3)
+    RETURNING id, created_at, version`
+
+	args := []any{person.Name, person.BirthDate, person.Biography}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&person.ID, &person.CreatedAt, &person.Version)
+	if err != nil {
+		return fmt.Errorf("data: insert a person: %s", err)
+	}
+
+	return nil
+}
+
+func (m PersonModel) Get(id int64) (*Person, error) {
+	if id <= 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+    SELECT id, name, birth_date, biography, created_at, version
+    FROM people
+    WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var person Person
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&person.ID,
+		&person.Name,
+		&person.BirthDate,
+		&person.Biography,
+		&person.CreatedAt,
+		&person.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, fmt.Errorf("data: query a person: %s", err)
+		}
+	}
+
+	return &person, nil
+}
+
+func (m PersonModel) Update(person *Person) error {
+	query := `
+    UPDATE people
+    SET name = $1, birth_date = $2, biography = $3, version = version + 1
+    WHERE id = $4 AND version = $5
+    RETURNING version`
+
+	args := []any{person.Name, person.BirthDate, person.Biography, person.ID, person.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&person.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return fmt.Errorf("data: update a person: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (m PersonModel) Delete(id int64) error {
+	if id <= 0 {
+		return ErrRecordNotFound
+	}
+
+	stmt := `
+    DELETE FROM people
+    WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("data: delete a person: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m PersonModel) GetAll(name string, filter Filters) ([]Person, Metadata, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), id, name, birth_date, biography, created_at, version
+    FROM people
+    WHERE ($1 = '' OR name ILIKE '%%' || $1 || '%%')
+    ORDER BY %s %s, id ASC
+    LIMIT $2 OFFSET $3`, filter.sortColumn(), filter.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, likeEscaper.Replace(name), filter.limit(), filter.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query all people: %s", err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	people := []Person{}
+	for rows.Next() {
+		var person Person
+		err = rows.Scan(
+			&totalRecords,
+			&person.ID,
+			&person.Name,
+			&person.BirthDate,
+			&person.Biography,
+			&person.CreatedAt,
+			&person.Version,
+		)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a person: %s", err)
+		}
+
+		people = append(people, person)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate all people: %s", err)
+	}
+
+	return people, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
+
+type CreditModel struct {
+	DB *sql.DB
+}
+
+func (m CreditModel) Insert(credit *MovieCredit) error {
+	query := `
+    WITH c AS (
+        INSERT INTO movie_credits (movie_id, person_id, role, character, billing_order)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, person_id
+    )
+    SELECT c.id, people.name
+    FROM c
+    INNER JOIN people ON people.id = c.person_id`
+
+	args := []any{credit.MovieID, credit.PersonID, credit.Role, credit.Character, credit.BillingOrder}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&credit.ID, &credit.PersonName)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) {
+			switch {
+			case pgErr.Code == "23505":
+				return ErrDuplicateCredit
+			case pgErr.Code == "23503" && pgErr.Constraint == "movie_credits_person_id_fkey":
+				return ErrInvalidPerson
+			}
+		}
+
+		return fmt.Errorf("data: insert a movie credit: %s", err)
+	}
+
+	return nil
+}
+
+func (m CreditModel) Delete(movieID, creditID int64) error {
+	stmt := `
+    DELETE FROM movie_credits
+    WHERE id = $1 AND movie_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, creditID, movieID)
+	if err != nil {
+		return fmt.Errorf("data: delete a movie credit: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m CreditModel) GetAllForMovie(movieID int64) ([]MovieCredit, error) {
+	query := `
+    SELECT movie_credits.id, movie_id, person_id, people.name, role, character, billing_order
+    FROM movie_credits
+    INNER JOIN people ON people.id = movie_credits.person_id
+    WHERE movie_id = $1
+    ORDER BY CASE role WHEN 'director' THEN 0 WHEN 'writer' THEN 1 ELSE 2 END, billing_order, movie_credits.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return nil, fmt.Errorf("data: query credits of movie with id=%d: %s", movieID, err)
+	}
+	defer rows.Close()
+
+	credits := []MovieCredit{}
+	for rows.Next() {
+		var credit MovieCredit
+		err = rows.Scan(
+			&credit.ID,
+			&credit.MovieID,
+			&credit.PersonID,
+			&credit.PersonName,
+			&credit.Role,
+			&credit.Character,
+			&credit.BillingOrder,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a movie credit: %s", err)
+		}
+
+		credits = append(credits, credit)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate credits of movie with id=%d: %s", movieID, err)
+	}
+
+	return credits, nil
+}
+
+func (m CreditModel) GetAllForPerson(personID int64, filter Filters) ([]MovieCredit, Metadata, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), movie_credits.id, movie_id, movies.title, movies.year, person_id, role, character, billing_order
+    FROM movie_credits
+    INNER JOIN movies ON movies.id = movie_credits.movie_id
+    WHERE person_id = $1 AND movies.deleted_at IS NULL
+    ORDER BY %s %s, movie_credits.id ASC
+    LIMIT $2 OFFSET $3`, filter.sortColumn(), filter.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, personID, filter.limit(), filter.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query filmography of person with id=%d: %s", personID, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	credits := []MovieCredit{}
+	for rows.Next() {
+		var credit MovieCredit
+		err = rows.Scan(
+			&totalRecords,
+			&credit.ID,
+			&credit.MovieID,
+			&credit.MovieTitle,
+			&credit.MovieYear,
+			&credit.PersonID,
+			&credit.Role,
+			&credit.Character,
+			&credit.BillingOrder,
+		)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a movie credit: %s", err)
+		}
+
+		credits = append(credits, credit)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate filmography of person with id=%d: %s", personID, err)
+	}
+
+	return credits, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type ImageThumbnails map[string]string
+
+func (t *ImageThumbnails) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*t = nil
+		return nil
+	default:
+		return fmt.Errorf("data: cannot scan %T into image thumbnails", src)
+	}
+
+	return json.Unmarshal(b, t)
+}
+
+type MovieImage struct {
+	URL        string
+	Thumbnails ImageThumbnails
+	Keys       []string
+}
+
+func (m MovieModel) SetPoster(movie *Movie, poster MovieImage, userID int64) ([]string, error) {
+	oldKeys, err := m.setImage(movie, "poster", poster, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	movie.PosterURL = poster.URL
+	movie.PosterThumbnails = poster.Thumbnails
+
+	return oldKeys, nil
+}
+
+func (m MovieModel) SetBackdrop(movie *Movie, backdrop MovieImage, userID int64) ([]string, error) {
+	oldKeys, err := m.setImage(movie, "backdrop", backdrop, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	movie.BackdropURL = backdrop.URL
+	movie.BackdropThumbnails = backdrop.Thumbnails
+
+	return oldKeys, nil
+}
+
+func (m MovieModel) setImage(movie *Movie, kind string, img MovieImage, userID int64) ([]string, error) {
+	query := fmt.Sprintf(`
+    WITH old AS (
+        SELECT id, %[1]s_url, %[1]s_keys
+        FROM movies
+        WHERE id = $4 AND version = $5 AND deleted_at IS NULL
+        FOR UPDATE
+    ), mv AS (
+        UPDATE movies
+        SET %[1]s_url = $1, %[1]s_thumbnails = $2, %[1]s_keys = $3, version = movies.version + 1
+        FROM old
+        WHERE movies.id = old.id
+        RETURNING movies.id, movies.version, movies.title, movies.year, movies.runtime, movies.genres,
+            movies.deleted_at, movies.poster_url, movies.backdrop_url, old.%[1]s_url AS old_url, old.%[1]s_keys AS old_keys
+    ), %[2]s
+    SELECT version, old_keys FROM mv`, kind, movieRevisionCTE("$6", fmt.Sprintf("jsonb_build_object('%s_url', mv.old_url)", kind)))
+
+	thumbnails, err := json.Marshal(img.Thumbnails)
+	if err != nil {
+		return nil, fmt.Errorf("data: encode %s thumbnails: %s", kind, err)
+	}
+
+	args := []any{img.URL, thumbnails, pq.Array(img.Keys), movie.ID, movie.Version, userID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var oldKeys []string
+	err = m.DB.QueryRowContext(ctx, query, args...).Scan(&movie.Version, pq.Array(&oldKeys))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrEditConflict
+		default:
+			return nil, fmt.Errorf("data: set movie %s: %s", kind, err)
+		}
+	}
+
+	return oldKeys, nil
+}
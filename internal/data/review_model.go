@@ -0,0 +1,236 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateReview = errors.New("duplicate review")
+
+type ReviewModel struct {
+	DB *sql.DB
+}
+
+func (m ReviewModel) Insert(review *Review) error {
+	query := `
+    INSERT INTO reviews (user_id, movie_id, score, body)
+    VALUES ($1, $2, $3, $4)
+    RETURNING id, created_at, updated_at, version`
+
+	args := []any{review.UserID, review.MovieID, review.Score, review.Body}
+
+	return m.withRatingRefresh(review.MovieID, func(ctx context.Context, tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).
+			Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt, &review.Version)
+		if err != nil {
+			switch {
+			case isUniqueReviewConstraintError(err):
+				return ErrDuplicateReview
+			default:
+				return fmt.Errorf("data: insert a review: %s", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (m ReviewModel) Get(movieID, userID int64) (*Review, error) {
+	query := `
+    SELECT id, user_id, movie_id, score, body, created_at, updated_at, version
+    FROM reviews
+    WHERE movie_id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var review Review
+	err := m.DB.QueryRowContext(ctx, query, movieID, userID).Scan(
+		&review.ID,
+		&review.UserID,
+		&review.MovieID,
+		&review.Score,
+		&review.Body,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+		&review.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, fmt.Errorf("data: select a review: %s", err)
+		}
+	}
+
+	return &review, nil
+}
+
+func (m ReviewModel) Update(review *Review) error {
+	query := `
+    UPDATE reviews
+    SET score = $1, body = $2, updated_at = NOW(), version = version + 1
+    WHERE id = $3 AND version = $4
+    RETURNING updated_at, version`
+
+	args := []any{review.Score, review.Body, review.ID, review.Version}
+
+	return m.withRatingRefresh(review.MovieID, func(ctx context.Context, tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&review.UpdatedAt, &review.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrEditConflict
+			default:
+				return fmt.Errorf("data: update a review: %s", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (m ReviewModel) Delete(movieID, userID int64) error {
+	stmt := `
+    DELETE FROM reviews
+    WHERE movie_id = $1 AND user_id = $2`
+
+	return m.withRatingRefresh(movieID, func(ctx context.Context, tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, stmt, movieID, userID)
+		if err != nil {
+			return fmt.Errorf("data: delete a review: %s", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+func (m ReviewModel) GetAllForMovie(movieID int64, filter Filters) ([]Review, Metadata, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), id, user_id, movie_id, score, body, created_at, updated_at, version
+    FROM reviews
+    WHERE movie_id = $1
+    ORDER BY %s %s, id ASC
+    LIMIT $2 OFFSET $3`, filter.sortColumn(), filter.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID, filter.limit(), filter.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query reviews of movie with id=%d: %s", movieID, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	reviews := []Review{}
+	for rows.Next() {
+		var review Review
+		err = rows.Scan(
+			&totalRecords,
+			&review.ID,
+			&review.UserID,
+			&review.MovieID,
+			&review.Score,
+			&review.Body,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+			&review.Version,
+		)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a review of movie with id=%d: %s", movieID, err)
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate reviews of movie with id=%d: %s", movieID, err)
+	}
+
+	return reviews, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
+
+func (m ReviewModel) withRatingRefresh(movieID int64, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("data: begin review transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	// Reviews of the same movie are serialised so that each recount sees
+	// the others' changes.
+	err = tx.QueryRowContext(ctx, `SELECT id FROM movies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, movieID).Scan(&movieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return fmt.Errorf("data: lock movie with id=%d: %s", movieID, err)
+		}
+	}
+
+	err = fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	err = refreshMovieRating(ctx, tx, movieID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("data: commit review transaction: %s", err)
+	}
+
+	return nil
+}
+
+func refreshMovieRating(ctx context.Context, tx *sql.Tx, movieID int64) error {
+	// Ratings are derived from reviews, so they are kept out of the movie's
+	// version and revision history.
+	stmt := `
+    UPDATE movies
+    SET average_rating = rating.average, rating_count = rating.count
+    FROM (
+        SELECT COALESCE(AVG(score), 0)::numeric(4,2) AS average, COUNT(*) AS count
+        FROM reviews
+        WHERE movie_id = $1
+    ) AS rating
+    WHERE movies.id = $1`
+
+	_, err := tx.ExecContext(ctx, stmt, movieID)
+	if err != nil {
+		return fmt.Errorf("data: refresh rating of movie with id=%d: %s", movieID, err)
+	}
+
+	return nil
+}
+
+func isUniqueReviewConstraintError(err error) bool {
+	var pgErr *pq.Error
+	ok := errors.As(err, &pgErr)
+	if !ok {
+		return false
+	}
+	return pgErr.Code == "23505" && pgErr.Constraint == "reviews_user_movie_key"
+}
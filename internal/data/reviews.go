@@ -0,0 +1,24 @@
+package data
+
+import (
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+type Review struct {
+	ID        int64     `json:"id"`
+	MovieID   int64     `json:"movie_id"`
+	UserID    int64     `json:"user_id"`
+	Score     int32     `json:"score"`
+	Body      string    `json:"body,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Version   int32     `json:"version"`
+}
+
+func ValidateReview(v *validator.Validator, r *Review) {
+	v.CheckError(r.Score != 0, "score", "must be provided")
+	v.CheckError(r.Score >= 1 && r.Score <= 10, "score", "must be between 1 and 10")
+	v.CheckError(validator.LengthLessOrEqual(r.Body, 5000), "body", "must not be greater than 5000 characters")
+}
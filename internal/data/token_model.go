@@ -2,11 +2,15 @@ package data
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
+var ErrTokenReused = errors.New("token reused")
+
 type TokenModel struct {
 	DB *sql.DB
 }
@@ -54,3 +58,190 @@ func (m TokenModel) DeleteAllForUser(scope string, userID int64) error {
 
 	return nil
 }
+
+func (m TokenModel) NewPair(userID int64, accessTTL, refreshTTL time.Duration) (*Token, *Token, error) {
+	family, err := generateTokenFamily()
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: generate a token family: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: begin token pair transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	access, refresh, err := insertTokenPair(ctx, tx, userID, family, accessTTL, refreshTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: commit token pair: %s", err)
+	}
+
+	return access, refresh, nil
+}
+
+func (m TokenModel) Rotate(plaintext string, accessTTL, refreshTTL time.Duration) (*Token, *Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: begin token rotation transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+    SELECT user_id, family, expiry, used_at
+    FROM tokens
+    WHERE hash = $1 AND scope = $2
+    FOR UPDATE`
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	var (
+		userID int64
+		family []byte
+		expiry time.Time
+		usedAt *time.Time
+	)
+	err = tx.QueryRowContext(ctx, query, hash[:], ScopeRefresh).Scan(&userID, &family, &expiry, &usedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, nil, ErrRecordNotFound
+		default:
+			return nil, nil, fmt.Errorf("data: select a refresh token: %s", err)
+		}
+	}
+
+	// A refresh token is only ever presented twice if it has leaked, so every
+	// token issued from the same login is revoked.
+	if usedAt != nil {
+		_, err = tx.ExecContext(ctx, `DELETE FROM tokens WHERE family = $1`, family)
+		if err != nil {
+			return nil, nil, fmt.Errorf("data: revoke token family: %s", err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return nil, nil, fmt.Errorf("data: commit token family revocation: %s", err)
+		}
+
+		return nil, nil, ErrTokenReused
+	}
+
+	if !expiry.After(time.Now()) {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	stmt := `
+    UPDATE tokens SET used_at = NOW()
+    WHERE hash = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, hash[:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: mark a refresh token as used: %s", err)
+	}
+
+	stmt = `
+    DELETE FROM tokens
+    WHERE family = $1 AND expiry <= NOW()`
+
+	_, err = tx.ExecContext(ctx, stmt, family)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: delete expired tokens of family: %s", err)
+	}
+
+	access, refresh, err := insertTokenPair(ctx, tx, userID, family, accessTTL, refreshTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: commit token rotation: %s", err)
+	}
+
+	return access, refresh, nil
+}
+
+func insertTokenPair(ctx context.Context, tx *sql.Tx, userID int64, family []byte, accessTTL, refreshTTL time.Duration) (*Token, *Token, error) {
+	access, err := generateToken(ScopeAuthentication, userID, accessTTL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: generate a token: %s", err)
+	}
+
+	refresh, err := generateToken(ScopeRefresh, userID, refreshTTL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: generate a token: %s", err)
+	}
+
+	stmt := `
+    INSERT INTO tokens (hash, user_id, expiry, scope, family)
+    VALUES ($1, $2, $3, $4, $5), ($6, $7, $8, $9, $10)`
+
+	args := []any{
+		access.Hash, access.UserID, access.Expiry, access.Scope, family,
+		refresh.Hash, refresh.UserID, refresh.Expiry, refresh.Scope, family,
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: insert a token pair: %s", err)
+	}
+
+	access.Family, refresh.Family = family, family
+
+	return access, refresh, nil
+}
+
+func (m TokenModel) DeleteForToken(scope, plaintext string) error {
+	stmt := `
+    DELETE FROM tokens
+    WHERE (hash = $1 AND scope = $2)
+    OR family IN (SELECT family FROM tokens WHERE hash = $1 AND scope = $2 AND family IS NOT NULL)`
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, hash[:], scope)
+	if err != nil {
+		return fmt.Errorf("data: delete a token of scope %q: %s", scope, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m TokenModel) DeleteAllSessionsForUser(userID int64) error {
+	query := `
+    DELETE FROM tokens
+    WHERE scope IN ($1, $2) AND user_id = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, ScopeAuthentication, ScopeRefresh, userID)
+	if err != nil {
+		return fmt.Errorf("data: delete all sessions for user with id=%d: %s", userID, err)
+	}
+
+	return nil
+}
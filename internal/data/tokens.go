@@ -13,6 +13,7 @@ const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
 	ScopePasswordReset  = "password-reset"
+	ScopeRefresh        = "refresh"
 )
 
 type Token struct {
@@ -21,6 +22,7 @@ type Token struct {
 	UserID    int64     `json:"-"`
 	Expiry    time.Time `json:"expiry"`
 	Scope     string    `json:"-"`
+	Family    []byte    `json:"-"`
 }
 
 func ValidateTokenPlainText(v *validator.Validator, text string) {
@@ -50,3 +52,13 @@ func generateToken(scope string, userID int64, ttl time.Duration) (*Token, error
 
 	return token, nil
 }
+
+func generateTokenFamily() ([]byte, error) {
+	family := make([]byte, 16)
+	_, err := rand.Read(family)
+	if err != nil {
+		return nil, err
+	}
+
+	return family, nil
+}
@@ -0,0 +1,151 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type TranslationModel struct {
+	DB *sql.DB
+}
+
+func (m TranslationModel) GetAllForMovie(movieID int64) ([]MovieTranslation, error) {
+	query := `
+    SELECT movie_id, language, title, overview, updated_at
+    FROM movie_translations
+    WHERE movie_id = $1
+    ORDER BY language`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return nil, fmt.Errorf("data: query translations of movie with id=%d: %s", movieID, err)
+	}
+	defer rows.Close()
+
+	translations := []MovieTranslation{}
+	for rows.Next() {
+		var t MovieTranslation
+		err = rows.Scan(&t.MovieID, &t.Language, &t.Title, &t.Overview, &t.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("data: scan a movie translation: %s", err)
+		}
+
+		translations = append(translations, t)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("data: iterate translations of movie with id=%d: %s", movieID, err)
+	}
+
+	return translations, nil
+}
+
+func (m TranslationModel) Upsert(t *MovieTranslation) (bool, error) {
+	query := `
+    INSERT INTO movie_translations (movie_id, language, title, overview)
+    VALUES ($1, $2, $3, $4)
+    ON CONFLICT (movie_id, language) DO UPDATE
+    SET title = EXCLUDED.title, overview = EXCLUDED.overview, updated_at = NOW()
+    RETURNING updated_at, xmax = 0`
+
+	args := []any{t.MovieID, t.Language, t.Title, t.Overview}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var created bool
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&t.UpdatedAt, &created)
+	if err != nil {
+		return false, fmt.Errorf("data: upsert a movie translation: %s", err)
+	}
+
+	return created, nil
+}
+
+func (m TranslationModel) Delete(movieID int64, language string) error {
+	stmt := `
+    DELETE FROM movie_translations
+    WHERE movie_id = $1 AND language = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, movieID, language)
+	if err != nil {
+		return fmt.Errorf("data: delete a movie translation: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m TranslationModel) Localise(movies []Movie, languages []string) error {
+	if len(movies) == 0 || len(languages) == 0 {
+		return nil
+	}
+
+	query := `
+    SELECT DISTINCT ON (movie_id) movie_id, language, title, overview
+    FROM movie_translations
+    WHERE movie_id = ANY($1) AND language = ANY($2::text[])
+    ORDER BY movie_id, array_position($2::text[], language)`
+
+	ids := make([]int64, 0, len(movies))
+	for _, movie := range movies {
+		ids = append(ids, movie.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(ids), pq.Array(languages))
+	if err != nil {
+		return fmt.Errorf("data: query movie translations: %s", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]MovieTranslation, len(movies))
+	for rows.Next() {
+		var t MovieTranslation
+		err = rows.Scan(&t.MovieID, &t.Language, &t.Title, &t.Overview)
+		if err != nil {
+			return fmt.Errorf("data: scan a movie translation: %s", err)
+		}
+
+		byID[t.MovieID] = t
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("data: iterate movie translations: %s", err)
+	}
+
+	for i := range movies {
+		t, ok := byID[movies[i].ID]
+		if !ok {
+			continue
+		}
+
+		movies[i].OriginalTitle = movies[i].Title
+		movies[i].Title = t.Title
+		movies[i].Overview = t.Overview
+		movies[i].Language = t.Language
+	}
+
+	return nil
+}
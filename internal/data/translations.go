@@ -0,0 +1,36 @@
+package data
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+var LanguageRX = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{1,8})*$`)
+
+type MovieTranslation struct {
+	MovieID   int64     `json:"movie_id"`
+	Language  string    `json:"language"`
+	Title     string    `json:"title"`
+	Overview  string    `json:"overview,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NormaliseLanguage(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+}
+
+func ValidateLanguage(v *validator.Validator, key, language string) {
+	v.CheckError(validator.NotBlank(language), key, "must be provided")
+	v.CheckError(validator.LengthLessOrEqual(language, 35), key, "must not be greater than 35 characters")
+	v.CheckError(validator.Matches(language, LanguageRX), key, "must be a valid language tag")
+}
+
+func ValidateMovieTranslation(v *validator.Validator, t *MovieTranslation) {
+	ValidateLanguage(v, "language", t.Language)
+	v.CheckError(validator.NotBlank(t.Title), "title", "must be provided")
+	v.CheckError(validator.LengthLessOrEqual(t.Title, 500), "title", "must not be greater than 500 characters")
+	v.CheckError(validator.LengthLessOrEqual(t.Overview, 10000), "overview", "must not be greater than 10000 characters")
+}
@@ -0,0 +1,355 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateWatchlistItem = errors.New("duplicate watchlist item")
+
+const visibleWatchlistCount = `
+    SELECT COUNT(*)
+    FROM watchlist_items
+    INNER JOIN movies ON movies.id = watchlist_items.movie_id
+    WHERE watchlist_items.user_id = $1 AND movies.deleted_at IS NULL`
+
+type WatchlistModel struct {
+	DB *sql.DB
+}
+
+func (m WatchlistModel) Add(userID, movieID int64) (*WatchlistItem, error) {
+	query := `
+    INSERT INTO watchlist_items (user_id, movie_id, position)
+    SELECT $1, $2, COALESCE(MAX(position), 0) + 1
+    FROM watchlist_items
+    WHERE user_id = $1
+    RETURNING added_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("data: begin watchlist transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	err = lockWatchlists(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := WatchlistItem{Movie: Movie{ID: movieID}}
+	err = tx.QueryRowContext(ctx, query, userID, movieID).Scan(&item.AddedAt)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDuplicateWatchlistItem
+		}
+
+		return nil, fmt.Errorf("data: insert a watchlist item: %s", err)
+	}
+
+	// The new item is last, so its visible position is the number of items
+	// whose movies are not in the trash.
+	err = tx.QueryRowContext(ctx, visibleWatchlistCount, userID).Scan(&item.Position)
+	if err != nil {
+		return nil, fmt.Errorf("data: count watchlist items: %s", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("data: commit watchlist transaction: %s", err)
+	}
+
+	return &item, nil
+}
+
+func (m WatchlistModel) Remove(userID, movieID int64) error {
+	stmt := `
+    DELETE FROM watchlist_items
+    WHERE user_id = $1 AND movie_id = $2
+    RETURNING position`
+
+	shift := `
+    UPDATE watchlist_items
+    SET position = position - 1
+    WHERE user_id = $1 AND position > $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("data: begin watchlist transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	err = lockWatchlists(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	var position int32
+	err = tx.QueryRowContext(ctx, stmt, userID, movieID).Scan(&position)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return fmt.Errorf("data: delete a watchlist item: %s", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, shift, userID, position)
+	if err != nil {
+		return fmt.Errorf("data: shift watchlist items: %s", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("data: commit watchlist transaction: %s", err)
+	}
+
+	return nil
+}
+
+func (m WatchlistModel) Move(userID, movieID int64, position int32) (*WatchlistItem, error) {
+	// Stored positions are dense over every item, including those whose
+	// movies are in the trash, while clients only see the visible ones. The
+	// requested visible position is mapped onto the stored position of the
+	// visible item currently holding it.
+	query := `
+    WITH visible AS (
+        SELECT watchlist_items.movie_id, watchlist_items.position,
+            ROW_NUMBER() OVER (ORDER BY watchlist_items.position) AS n
+        FROM watchlist_items
+        INNER JOIN movies ON movies.id = watchlist_items.movie_id
+        WHERE watchlist_items.user_id = $1 AND movies.deleted_at IS NULL
+    )
+    SELECT item.position, target.position
+    FROM visible AS item, visible AS target
+    WHERE item.movie_id = $2
+    AND target.n = LEAST($3, (SELECT COUNT(*) FROM visible))`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("data: begin watchlist transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	err = lockWatchlists(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var current, target int32
+	err = tx.QueryRowContext(ctx, query, userID, movieID, position).Scan(&current, &target)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, fmt.Errorf("data: select a watchlist item: %s", err)
+		}
+	}
+
+	var shift string
+	switch {
+	case target < current:
+		shift = `
+        UPDATE watchlist_items
+        SET position = position + 1
+        WHERE user_id = $1 AND position >= $2 AND position < $3`
+	case target > current:
+		shift = `
+        UPDATE watchlist_items
+        SET position = position - 1
+        WHERE user_id = $1 AND position > $3 AND position <= $2`
+	}
+
+	if shift != "" {
+		_, err = tx.ExecContext(ctx, shift, userID, target, current)
+		if err != nil {
+			return nil, fmt.Errorf("data: shift watchlist items: %s", err)
+		}
+	}
+
+	stmt := `
+    UPDATE watchlist_items
+    SET position = $3
+    WHERE user_id = $1 AND movie_id = $2
+    RETURNING added_at`
+
+	item := WatchlistItem{Movie: Movie{ID: movieID}}
+	err = tx.QueryRowContext(ctx, stmt, userID, movieID, target).Scan(&item.AddedAt)
+	if err != nil {
+		return nil, fmt.Errorf("data: move a watchlist item: %s", err)
+	}
+
+	err = tx.QueryRowContext(ctx, visibleWatchlistCount+` AND watchlist_items.position <= $2`, userID, target).Scan(&item.Position)
+	if err != nil {
+		return nil, fmt.Errorf("data: count watchlist items: %s", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("data: commit watchlist transaction: %s", err)
+	}
+
+	return &item, nil
+}
+
+func lockWatchlists(ctx context.Context, tx *sql.Tx, userIDs ...int64) error {
+	// Positions are dense per user, so every change to a user's watchlist is
+	// serialised on the user's row.
+	stmt := `
+    SELECT id FROM users
+    WHERE id = ANY($1)
+    ORDER BY id
+    FOR NO KEY UPDATE`
+
+	_, err := tx.ExecContext(ctx, stmt, pq.Array(userIDs))
+	if err != nil {
+		return fmt.Errorf("data: lock watchlists: %s", err)
+	}
+
+	return nil
+}
+
+func (m WatchlistModel) GetAll(userID int64, filter Filters) ([]WatchlistItem, Metadata, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), %s, position, added_at
+    FROM (
+        SELECT movies.*, watchlist_items.added_at,
+            ROW_NUMBER() OVER (ORDER BY watchlist_items.position) AS position
+        FROM watchlist_items
+        INNER JOIN movies ON movies.id = watchlist_items.movie_id
+        WHERE watchlist_items.user_id = $1 AND movies.deleted_at IS NULL
+    ) AS items
+    ORDER BY %s %s, id ASC
+    LIMIT $2 OFFSET $3`, strings.Join(MovieFields, ", "), filter.sortColumn(), filter.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, filter.limit(), filter.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query watchlist of user with id=%d: %s", userID, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	items := []WatchlistItem{}
+	for rows.Next() {
+		var item WatchlistItem
+		dests := append([]any{&totalRecords}, item.Movie.scanDests(MovieFields)...)
+		err = rows.Scan(append(dests, &item.Position, &item.AddedAt)...)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a watchlist item: %s", err)
+		}
+
+		items = append(items, item)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate watchlist of user with id=%d: %s", userID, err)
+	}
+
+	return items, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
+
+type WatchedModel struct {
+	DB *sql.DB
+}
+
+func (m WatchedModel) Add(userID, movieID int64, watchedOn time.Time) (*WatchedMovie, error) {
+	query := `
+    INSERT INTO watched_movies (user_id, movie_id, watched_on)
+    VALUES ($1, $2, $3)
+    ON CONFLICT (user_id, movie_id) DO UPDATE SET watched_on = EXCLUDED.watched_on
+    RETURNING watched_on`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	watched := WatchedMovie{Movie: Movie{ID: movieID}}
+	err := m.DB.QueryRowContext(ctx, query, userID, movieID, watchedOn).Scan(&watched.WatchedOn)
+	if err != nil {
+		return nil, fmt.Errorf("data: insert a watched movie: %s", err)
+	}
+
+	return &watched, nil
+}
+
+func (m WatchedModel) Remove(userID, movieID int64) error {
+	stmt := `
+    DELETE FROM watched_movies
+    WHERE user_id = $1 AND movie_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, userID, movieID)
+	if err != nil {
+		return fmt.Errorf("data: delete a watched movie: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m WatchedModel) GetAll(userID int64, filter Filters) ([]WatchedMovie, Metadata, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) OVER(), %s, watched_on
+    FROM watched_movies
+    INNER JOIN movies ON movies.id = watched_movies.movie_id
+    WHERE user_id = $1 AND deleted_at IS NULL
+    ORDER BY %s %s, id ASC
+    LIMIT $2 OFFSET $3`, strings.Join(MovieFields, ", "), filter.sortColumn(), filter.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, filter.limit(), filter.offset())
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: query watched movies of user with id=%d: %s", userID, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	watched := []WatchedMovie{}
+	for rows.Next() {
+		var w WatchedMovie
+		dests := append([]any{&totalRecords}, w.Movie.scanDests(MovieFields)...)
+		err = rows.Scan(append(dests, &w.WatchedOn)...)
+		if err != nil {
+			return nil, Metadata{}, fmt.Errorf("data: scan a watched movie: %s", err)
+		}
+
+		watched = append(watched, w)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("data: iterate watched movies of user with id=%d: %s", userID, err)
+	}
+
+	return watched, makeMetadata(totalRecords, filter.Page, filter.PageSize), nil
+}
@@ -0,0 +1,28 @@
+package data
+
+import (
+	"time"
+
+	"huytran2000-hcmus/greenlight/internal/validator"
+)
+
+type WatchlistItem struct {
+	Movie    Movie     `json:"movie"`
+	Position int32     `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+type WatchedMovie struct {
+	Movie     Movie     `json:"movie"`
+	WatchedOn time.Time `json:"watched_on"`
+}
+
+func ValidateWatchlistPosition(v *validator.Validator, position int32) {
+	v.CheckError(position != 0, "position", "must be provided")
+	v.CheckError(position > 0, "position", "must be a positive integer")
+}
+
+func ValidateWatchedOn(v *validator.Validator, watchedOn time.Time) {
+	v.CheckError(!watchedOn.IsZero(), "watched_on", "must be provided")
+	v.CheckError(!watchedOn.After(time.Now()), "watched_on", "must not be in the future")
+}
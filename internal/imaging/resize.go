@@ -0,0 +1,96 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+func Resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if width <= 0 || srcW <= width {
+		return src
+	}
+
+	height := srcH * width / srcW
+	if height < 1 {
+		height = 1
+	}
+
+	pixel := pixelReader(src)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := bounds.Min.Y + (y+1)*srcH/height
+		if y1 == y0 {
+			y1++
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := bounds.Min.X + (x+1)*srcW/width
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := pixel(sx, sy)
+					r, g, b, a = r+cr, g+cg, b+cb, a+ca
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+func pixelReader(src image.Image) func(x, y int) (r, g, b, a uint32) {
+	// Pixels are returned as premultiplied 8-bit channels. The types the
+	// decoders produce are read straight from their buffers so that the hot
+	// loop avoids the allocation in image.Image.At.
+	switch img := src.(type) {
+	case *image.RGBA:
+		return func(x, y int) (r, g, b, a uint32) {
+			p := img.Pix[img.PixOffset(x, y):]
+			return uint32(p[0]), uint32(p[1]), uint32(p[2]), uint32(p[3])
+		}
+	case *image.NRGBA:
+		return func(x, y int) (r, g, b, a uint32) {
+			p := img.Pix[img.PixOffset(x, y):]
+			a = uint32(p[3])
+			return uint32(p[0]) * a / 0xff, uint32(p[1]) * a / 0xff, uint32(p[2]) * a / 0xff, a
+		}
+	case *image.YCbCr:
+		return func(x, y int) (r, g, b, a uint32) {
+			ci := img.COffset(x, y)
+			cr, cg, cb := color.YCbCrToRGB(img.Y[img.YOffset(x, y)], img.Cb[ci], img.Cr[ci])
+			return uint32(cr), uint32(cg), uint32(cb), 0xff
+		}
+	case *image.NYCbCrA:
+		return func(x, y int) (r, g, b, a uint32) {
+			ci := img.COffset(x, y)
+			cr, cg, cb := color.YCbCrToRGB(img.Y[img.YOffset(x, y)], img.Cb[ci], img.Cr[ci])
+			a = uint32(img.A[img.AOffset(x, y)])
+			return uint32(cr) * a / 0xff, uint32(cg) * a / 0xff, uint32(cb) * a / 0xff, a
+		}
+	case *image.Gray:
+		return func(x, y int) (r, g, b, a uint32) {
+			v := uint32(img.Pix[img.PixOffset(x, y)])
+			return v, v, v, 0xff
+		}
+	default:
+		return func(x, y int) (r, g, b, a uint32) {
+			r, g, b, a = src.At(x, y).RGBA()
+			return r >> 8, g >> 8, b >> 8, a >> 8
+		}
+	}
+}
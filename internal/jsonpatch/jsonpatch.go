@@ -0,0 +1,373 @@
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch   = errors.New("invalid patch document")
+	errPathNotFound   = errors.New("path does not exist")
+	errInvalidPointer = errors.New("invalid JSON pointer")
+	errInvalidIndex   = errors.New("invalid array index")
+	errTestFailed     = errors.New("test failed")
+)
+
+type OperationError struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %s", e.Index, e.Op, e.Err)
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []operation
+	err := json.Unmarshal(patch, &ops)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	node, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, fmt.Errorf("%w: operation %d is missing \"path\"", ErrInvalidPatch, i)
+		}
+
+		node, err = applyOperation(node, op)
+		if err != nil {
+			if errors.Is(err, ErrInvalidPatch) {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+
+			return nil, &OperationError{Index: i, Op: op.Op, Path: *op.Path, Err: err}
+		}
+	}
+
+	return json.Marshal(node)
+}
+
+func applyOperation(node any, op operation) (any, error) {
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: missing \"value\"", ErrInvalidPatch)
+		}
+
+		val, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(node, path, val)
+		case "replace":
+			node, _, err = remove(node, path)
+			if err != nil {
+				return nil, err
+			}
+			return add(node, path, val)
+		default:
+			current, err := get(node, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, val) {
+				return nil, errTestFailed
+			}
+			return node, nil
+		}
+	case "remove":
+		node, _, err = remove(node, path)
+		return node, err
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: missing \"from\"", ErrInvalidPatch)
+		}
+
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var val any
+		if op.Op == "move" {
+			if *op.Path != *op.From && strings.HasPrefix(*op.Path, *op.From+"/") {
+				return nil, errors.New("cannot move a value into one of its children")
+			}
+			node, val, err = remove(node, from)
+		} else {
+			val, err = get(node, from)
+			if err == nil {
+				val, err = deepCopy(val)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return add(node, path, val)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errInvalidPointer
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errInvalidIndex
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, errInvalidIndex
+	}
+
+	if i >= length {
+		return 0, errPathNotFound
+	}
+
+	return i, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, errPathNotFound
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, errPathNotFound
+		}
+	}
+
+	return node, nil
+}
+
+func add(node any, path []string, val any) (any, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			n[token] = val
+			return n, nil
+		}
+
+		child, ok := n[token]
+		if !ok {
+			return nil, errPathNotFound
+		}
+
+		child, err := add(child, path[1:], val)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+
+		return n, nil
+	case []any:
+		if len(path) == 1 {
+			if token == "-" {
+				return append(n, val), nil
+			}
+
+			i, err := arrayIndex(token, len(n)+1)
+			if err != nil {
+				return nil, err
+			}
+
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = val
+
+			return n, nil
+		}
+
+		i, err := arrayIndex(token, len(n))
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := add(n[i], path[1:], val)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+
+		return n, nil
+	default:
+		return nil, errPathNotFound
+	}
+}
+
+func remove(node any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, node, nil
+	}
+
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, errPathNotFound
+		}
+
+		if len(path) == 1 {
+			delete(n, token)
+			return n, child, nil
+		}
+
+		child, removed, err := remove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = child
+
+		return n, removed, nil
+	case []any:
+		i, err := arrayIndex(token, len(n))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(path) == 1 {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+
+		child, removed, err := remove(n[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = child
+
+		return n, removed, nil
+	default:
+		return nil, nil, errPathNotFound
+	}
+}
+
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		// 1, 1.0 and 1e0 are the same JSON number.
+		x, okX := new(big.Rat).SetString(a.String())
+		y, okY := new(big.Rat).SetString(b.String())
+		return okX && okY && x.Cmp(y) == 0
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for key, val := range a {
+			other, ok := b[key]
+			if !ok || !equal(val, other) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return a == b
+	}
+}
+
+func decode(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func deepCopy(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return decode(b)
+}
@@ -0,0 +1,285 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "add object member",
+			doc:   `{"title":"Moana"}`,
+			patch: `[{"op":"add","path":"/year","value":2016}]`,
+			want:  `{"title":"Moana","year":2016}`,
+		},
+		{
+			name:  "add replaces existing member",
+			doc:   `{"year":2015}`,
+			patch: `[{"op":"add","path":"/year","value":2016}]`,
+			want:  `{"year":2016}`,
+		},
+		{
+			name:  "add inserts into array",
+			doc:   `{"genres":["a","c"]}`,
+			patch: `[{"op":"add","path":"/genres/1","value":"b"}]`,
+			want:  `{"genres":["a","b","c"]}`,
+		},
+		{
+			name:  "add appends to array",
+			doc:   `{"genres":["a"]}`,
+			patch: `[{"op":"add","path":"/genres/-","value":"b"}]`,
+			want:  `{"genres":["a","b"]}`,
+		},
+		{
+			name:  "add at array length",
+			doc:   `{"genres":["a"]}`,
+			patch: `[{"op":"add","path":"/genres/1","value":"b"}]`,
+			want:  `{"genres":["a","b"]}`,
+		},
+		{
+			name:  "add whole document",
+			doc:   `{"title":"Moana"}`,
+			patch: `[{"op":"add","path":"","value":{"title":"Up"}}]`,
+			want:  `{"title":"Up"}`,
+		},
+		{
+			name:  "remove object member",
+			doc:   `{"title":"Moana","year":2016}`,
+			patch: `[{"op":"remove","path":"/year"}]`,
+			want:  `{"title":"Moana"}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"genres":["a","b","c"]}`,
+			patch: `[{"op":"remove","path":"/genres/1"}]`,
+			want:  `{"genres":["a","c"]}`,
+		},
+		{
+			name:  "replace nested value",
+			doc:   `{"genres":["a","b"]}`,
+			patch: `[{"op":"replace","path":"/genres/0","value":"z"}]`,
+			want:  `{"genres":["z","b"]}`,
+		},
+		{
+			name:  "move member",
+			doc:   `{"a":{"b":1},"c":{}}`,
+			patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`,
+			want:  `{"a":{},"c":{"d":1}}`,
+		},
+		{
+			name:  "move array element",
+			doc:   `{"genres":["a","b","c"]}`,
+			patch: `[{"op":"move","from":"/genres/0","path":"/genres/-"}]`,
+			want:  `{"genres":["b","c","a"]}`,
+		},
+		{
+			name:  "copy is independent of source",
+			doc:   `{"a":{"b":[1]}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`,
+			want:  `{"a":{"b":[1]},"c":{"b":[1,2]}}`,
+		},
+		{
+			name:  "test passes",
+			doc:   `{"title":"Moana","genres":["a"],"meta":{"x":null,"y":true}}`,
+			patch: `[{"op":"test","path":"/title","value":"Moana"},{"op":"test","path":"/genres","value":["a"]},{"op":"test","path":"/meta","value":{"y":true,"x":null}}]`,
+			want:  `{"title":"Moana","genres":["a"],"meta":{"x":null,"y":true}}`,
+		},
+		{
+			name:  "test compares numbers by value",
+			doc:   `{"runtime":1,"rating":2.50}`,
+			patch: `[{"op":"test","path":"/runtime","value":1.0},{"op":"test","path":"/runtime","value":1e0},{"op":"test","path":"/rating","value":2.5}]`,
+			want:  `{"runtime":1,"rating":2.5}`,
+		},
+		{
+			name:  "pointer escaping",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":3}`,
+		},
+		{
+			name:  "pointer escape order",
+			doc:   `{"~1":1}`,
+			patch: `[{"op":"test","path":"/~01","value":1}]`,
+			want:  `{"~1":1}`,
+		},
+		{
+			name:  "empty member name",
+			doc:   `{"":1}`,
+			patch: `[{"op":"replace","path":"/","value":2}]`,
+			want:  `{"":2}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		patch     string
+		wantErr   error
+		wantOpErr bool
+	}{
+		{
+			name:    "patch is not an array",
+			doc:     `{}`,
+			patch:   `{"op":"add"}`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "missing path",
+			doc:     `{}`,
+			patch:   `[{"op":"remove"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "missing value",
+			doc:     `{}`,
+			patch:   `[{"op":"add","path":"/a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "missing from",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"copy","path":"/b"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{}`,
+			patch:   `[{"op":"frobnicate","path":"/a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:      "remove missing member",
+			doc:       `{}`,
+			patch:     `[{"op":"remove","path":"/a"}]`,
+			wantErr:   errPathNotFound,
+			wantOpErr: true,
+		},
+		{
+			name:      "replace missing member",
+			doc:       `{}`,
+			patch:     `[{"op":"replace","path":"/a","value":1}]`,
+			wantErr:   errPathNotFound,
+			wantOpErr: true,
+		},
+		{
+			name:      "add to missing parent",
+			doc:       `{}`,
+			patch:     `[{"op":"add","path":"/a/b","value":1}]`,
+			wantErr:   errPathNotFound,
+			wantOpErr: true,
+		},
+		{
+			name:      "array index past end",
+			doc:       `{"a":[1]}`,
+			patch:     `[{"op":"add","path":"/a/2","value":1}]`,
+			wantErr:   errPathNotFound,
+			wantOpErr: true,
+		},
+		{
+			name:      "array index with leading zero",
+			doc:       `{"a":[1,2]}`,
+			patch:     `[{"op":"remove","path":"/a/01"}]`,
+			wantErr:   errInvalidIndex,
+			wantOpErr: true,
+		},
+		{
+			name:      "pointer without leading slash",
+			doc:       `{"a":1}`,
+			patch:     `[{"op":"remove","path":"a"}]`,
+			wantErr:   errInvalidPointer,
+			wantOpErr: true,
+		},
+		{
+			name:      "test fails on different value",
+			doc:       `{"a":1}`,
+			patch:     `[{"op":"test","path":"/a","value":2}]`,
+			wantErr:   errTestFailed,
+			wantOpErr: true,
+		},
+		{
+			name:      "test fails on different type",
+			doc:       `{"a":1}`,
+			patch:     `[{"op":"test","path":"/a","value":"1"}]`,
+			wantErr:   errTestFailed,
+			wantOpErr: true,
+		},
+		{
+			name:      "test fails on different array length",
+			doc:       `{"a":[1]}`,
+			patch:     `[{"op":"test","path":"/a","value":[1,1]}]`,
+			wantErr:   errTestFailed,
+			wantOpErr: true,
+		},
+		{
+			name:      "move into own child",
+			doc:       `{"a":{"b":{}}}`,
+			patch:     `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			wantOpErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %q; want %q", err, tt.wantErr)
+			}
+
+			var opErr *OperationError
+			if errors.As(err, &opErr) != tt.wantOpErr {
+				t.Errorf("got error %q; want operation error: %t", err, tt.wantOpErr)
+			}
+		})
+	}
+}
+
+func TestApplyIsAtomicPerCall(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	_, err := Apply(doc, []byte(`[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":3}]`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	assertJSONEqual(t, doc, `{"a":1}`)
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid JSON %q: %s", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid JSON %q: %s", want, err)
+	}
+
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+
+	for key, val := range p {
+		if val == nil {
+			delete(t, key)
+			continue
+		}
+
+		t[key] = merge(t[key], val)
+	}
+
+	return t
+}
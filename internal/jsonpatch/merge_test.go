@@ -0,0 +1,46 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{name: "replace member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "add member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "null removes member", doc: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{name: "null leaves others", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{name: "arrays are replaced", doc: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "value replaced by object", doc: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{name: "nested merge", doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{name: "array of objects replaced", doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{name: "non object patch replaces", doc: `{"a":"foo"}`, patch: `["c"]`, want: `["c"]`},
+		{name: "non object target", doc: `["a"]`, patch: `{"a":"b"}`, want: `{"a":"b"}`},
+		{name: "nested null in new member", doc: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+		{name: "numbers are kept exact", doc: `{"a":1}`, patch: `{"b":12345678901234567890}`, want: `{"a":1,"b":12345678901234567890}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	_, err := MergePatch([]byte(`{}`), []byte(`{"a":`))
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("got error %v; want %v", err, ErrInvalidPatch)
+	}
+}
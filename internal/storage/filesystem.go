@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type FileSystem struct {
+	root    string
+	baseURL string
+}
+
+func NewFileSystem(root, baseURL string) (*FileSystem, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve storage root: %s", err)
+	}
+
+	err = os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("create storage root: %s", err)
+	}
+
+	return &FileSystem{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (fs *FileSystem) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	name, err := fs.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return fmt.Errorf("create storage directory: %s", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %q: %s", key, err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("close %q: %s", key, err)
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return fmt.Errorf("store %q: %s", key, err)
+	}
+
+	return nil
+}
+
+func (fs *FileSystem) Delete(ctx context.Context, key string) error {
+	name, err := fs.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete %q: %s", key, err)
+	}
+
+	return nil
+}
+
+func (fs *FileSystem) URL(key string) string {
+	return fs.baseURL + "/" + key
+}
+
+func (fs *FileSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.FileServer(http.Dir(fs.root)).ServeHTTP(w, r)
+}
+
+func (fs *FileSystem) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(fs.root, filepath.FromSlash(clean)), nil
+}
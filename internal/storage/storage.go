@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS movies_genres_idx;
+
+DROP INDEX IF EXISTS movies_title_trgm_idx;
+
+DROP INDEX IF EXISTS movies_title_tsv_idx;
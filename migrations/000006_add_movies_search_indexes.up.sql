@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS movies_title_tsv_idx ON movies USING GIN (to_tsvector('simple', title));
+
+CREATE INDEX IF NOT EXISTS movies_title_trgm_idx ON movies USING GIN (title gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS movies_genres_idx ON movies USING GIN (genres);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS movie_revisions (
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    version integer NOT NULL,
+    user_id bigint REFERENCES users ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    before jsonb,
+    after jsonb NOT NULL,
+    PRIMARY KEY (movie_id, version)
+);
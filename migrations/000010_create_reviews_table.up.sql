@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS reviews (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    score integer NOT NULL,
+    body text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT reviews_score_check CHECK (score BETWEEN 1 AND 10),
+    CONSTRAINT reviews_user_movie_key UNIQUE (user_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_movie_id_idx ON reviews (movie_id);
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS average_rating numeric(4, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS rating_count integer NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS movies_average_rating_idx ON movies (average_rating);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS watchlist_items (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    position integer NOT NULL,
+    added_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS watchlist_items_user_id_position_idx ON watchlist_items (user_id, position);
+
+CREATE TABLE IF NOT EXISTS watched_movies (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    watched_on date NOT NULL DEFAULT CURRENT_DATE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS watched_movies_user_id_watched_on_idx ON watched_movies (user_id, watched_on);
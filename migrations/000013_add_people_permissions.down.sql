@@ -0,0 +1 @@
+DELETE FROM permissions WHERE code IN ('people:read', 'people:write');
@@ -0,0 +1,105 @@
+CREATE TABLE IF NOT EXISTS genres (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS genres_name_key ON genres (lower(name));
+
+CREATE TABLE IF NOT EXISTS genre_aliases (
+    alias text PRIMARY KEY,
+    genre_id bigint NOT NULL REFERENCES genres ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS genre_aliases_genre_id_idx ON genre_aliases (genre_id);
+
+INSERT INTO genres (name)
+VALUES
+    ('action'),
+    ('adventure'),
+    ('animation'),
+    ('biography'),
+    ('comedy'),
+    ('crime'),
+    ('documentary'),
+    ('drama'),
+    ('family'),
+    ('fantasy'),
+    ('history'),
+    ('horror'),
+    ('music'),
+    ('musical'),
+    ('mystery'),
+    ('romance'),
+    ('sci-fi'),
+    ('sport'),
+    ('thriller'),
+    ('war'),
+    ('western')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO genre_aliases (alias, genre_id)
+SELECT alias, genres.id
+FROM (VALUES
+    ('science fiction', 'sci-fi'),
+    ('science-fiction', 'sci-fi'),
+    ('scifi', 'sci-fi'),
+    ('sf', 'sci-fi'),
+    ('biopic', 'biography'),
+    ('doc', 'documentary'),
+    ('romcom', 'romance'),
+    ('sports', 'sport')
+) AS a(alias, name)
+INNER JOIN genres ON genres.name = a.name
+ON CONFLICT DO NOTHING;
+
+INSERT INTO genres (name)
+SELECT DISTINCT ON (lower(trim(g))) trim(g)
+FROM movies, unnest(genres) AS g
+WHERE trim(g) <> ''
+  AND NOT EXISTS (SELECT 1 FROM genre_aliases WHERE alias = lower(trim(g)))
+ORDER BY lower(trim(g)), trim(g)
+ON CONFLICT DO NOTHING;
+
+-- Every name is also stored as an alias so names and aliases share one
+-- uniqueness constraint.
+INSERT INTO genre_aliases (alias, genre_id)
+SELECT lower(name), id
+FROM genres
+ON CONFLICT DO NOTHING;
+
+WITH canonical AS (
+    SELECT movies.id, ARRAY(
+        SELECT c.name
+        FROM unnest(movies.genres) WITH ORDINALITY AS x(g, n)
+        INNER JOIN LATERAL (
+            SELECT genres.name FROM genres WHERE lower(genres.name) = lower(trim(x.g))
+            UNION ALL
+            SELECT genres.name FROM genre_aliases
+            INNER JOIN genres ON genres.id = genre_aliases.genre_id
+            WHERE genre_aliases.alias = lower(trim(x.g))
+            LIMIT 1
+        ) AS c ON TRUE
+        GROUP BY c.name
+        ORDER BY MIN(x.n)
+    ) AS genres
+    FROM movies
+), old AS (
+    SELECT movies.id, movies.title, movies.year, movies.runtime, movies.genres
+    FROM movies
+    INNER JOIN canonical ON canonical.id = movies.id
+    WHERE movies.genres IS DISTINCT FROM canonical.genres
+    AND cardinality(canonical.genres) > 0
+), mv AS (
+    UPDATE movies
+    SET genres = canonical.genres, version = movies.version + 1
+    FROM canonical, old
+    WHERE movies.id = canonical.id AND movies.id = old.id
+    RETURNING movies.id, movies.version, movies.title, movies.year, movies.runtime, movies.genres
+)
+INSERT INTO movie_revisions (movie_id, version, before, after)
+SELECT mv.id, mv.version, to_jsonb(old) - 'id',
+    jsonb_build_object('title', mv.title, 'year', mv.year, 'runtime', mv.runtime, 'genres', mv.genres)
+FROM mv
+INNER JOIN old ON old.id = mv.id;
@@ -0,0 +1,5 @@
+ALTER TABLE movies DROP COLUMN IF EXISTS poster_keys;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS poster_thumbnails;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS poster_url;
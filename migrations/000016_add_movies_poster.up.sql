@@ -0,0 +1,5 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS poster_url text NOT NULL DEFAULT '';
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS poster_thumbnails jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS poster_keys text[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    visibility text NOT NULL DEFAULT 'private',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT collections_visibility_check CHECK (visibility IN ('public', 'private'))
+);
+
+CREATE INDEX IF NOT EXISTS collections_user_id_idx ON collections (user_id);
+
+CREATE INDEX IF NOT EXISTS collections_public_idx ON collections (created_at) WHERE visibility = 'public';
+
+CREATE TABLE IF NOT EXISTS collection_movies (
+    collection_id bigint NOT NULL REFERENCES collections ON DELETE CASCADE,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    position integer NOT NULL,
+    PRIMARY KEY (collection_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS collection_movies_movie_id_idx ON collection_movies (movie_id);
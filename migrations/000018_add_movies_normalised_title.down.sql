@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS movies_normalised_title_trgm_idx;
+
+DROP FUNCTION IF EXISTS normalise_title(text);
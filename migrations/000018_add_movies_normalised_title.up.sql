@@ -0,0 +1,7 @@
+CREATE OR REPLACE FUNCTION normalise_title(title text) RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$
+    SELECT regexp_replace(trim(regexp_replace(lower(title), '[^[:alnum:]]+', ' ', 'g')), '^(the|a|an) ', '')
+$$;
+
+CREATE INDEX IF NOT EXISTS movies_normalised_title_trgm_idx ON movies USING GIN (normalise_title(title) gin_trgm_ops);
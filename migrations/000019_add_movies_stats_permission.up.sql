@@ -0,0 +1,3 @@
+INSERT INTO permissions (code)
+VALUES
+    ('movies:stats');
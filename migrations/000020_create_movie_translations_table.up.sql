@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS movie_translations (
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    language text NOT NULL,
+    title text NOT NULL,
+    overview text NOT NULL DEFAULT '',
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (movie_id, language),
+    CONSTRAINT movie_translations_language_check CHECK (language = lower(language))
+);
+
+CREATE INDEX IF NOT EXISTS movie_translations_title_tsv_idx ON movie_translations USING GIN (to_tsvector('simple', title));
+
+CREATE INDEX IF NOT EXISTS movie_translations_title_trgm_idx ON movie_translations USING GIN (title gin_trgm_ops);
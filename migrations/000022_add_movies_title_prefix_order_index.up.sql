@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS movies_title_prefix_order_idx ON movies ((lower(title) COLLATE "C"), id) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS movies_title_prefix_idx;
@@ -0,0 +1,5 @@
+ALTER TABLE movies DROP COLUMN IF EXISTS backdrop_keys;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS backdrop_thumbnails;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS backdrop_url;
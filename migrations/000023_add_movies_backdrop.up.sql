@@ -0,0 +1,5 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS backdrop_url text NOT NULL DEFAULT '';
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS backdrop_thumbnails jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS backdrop_keys text[] NOT NULL DEFAULT '{}';